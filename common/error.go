@@ -1,15 +1,112 @@
 package common
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
+
+// Kind classifies an error by what the caller can do about it.
+type Kind string
+
+const (
+	KindNotFound     Kind = "not_found"
+	KindConflict     Kind = "conflict"
+	KindValidation   Kind = "validation"
+	KindUnauthorized Kind = "unauthorized"
+	KindForbidden    Kind = "forbidden"
+	KindInternal     Kind = "internal"
+	KindTimeout      Kind = "timeout"
+)
+
+// Error is a domain error with a kind and a stable machine readable code.
+// Message is safe to show to clients; the wrapped Err and any context added
+// with fmt.Errorf are for logs only.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+// FieldError describes why one request field failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// NewError returns a domain error with no cause, for use as a sentinel.
+func NewError(kind Kind, code string, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// Invalid marks err, typically from decoding or validating a request, as a
+// validation error whose message can be shown to the client.
+func Invalid(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{Kind: KindValidation, Code: "invalid_request", Message: err.Error(), Err: err}
+}
+
+// AsError returns the outermost domain error in err's chain. A cancelled or
+// timed out context is a timeout; other errors without a domain error are
+// internal.
+func AsError(err error) *Error {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &Error{Kind: KindTimeout, Code: ErrTimeout.Code, Message: ErrTimeout.Message, Err: err}
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return &Error{Kind: KindTimeout, Code: ErrCanceled.Code, Message: ErrCanceled.Message, Err: err}
+	}
+
+	return &Error{Kind: KindInternal, Code: "internal", Message: "internal server error", Err: err}
+}
+
+// KindOf returns the kind of err.
+func KindOf(err error) Kind {
+	return AsError(err).Kind
+}
 
 var (
-	ErrNotFound         = errors.New("not found")
-	ErrFailedCreateData = errors.New("failed create data")
-	ErrFailedUpdateData = errors.New("failed update data")
-	ErrUnauthorized     = errors.New("user unauthorized")
-	ErrUploadFile       = errors.New("failed upload data")
-	ErrDeleteData       = errors.New("failed delete data")
-	ErrMustHavePrimary  = errors.New("must have primary")
-	ErrNotMatch         = errors.New("do not match")
-	ErrExists           = errors.New("already exists")
+	ErrNotFound            = NewError(KindNotFound, "not_found", "not found")
+	ErrFailedCreateData    = NewError(KindInternal, "create_failed", "failed create data")
+	ErrFailedUpdateData    = NewError(KindInternal, "update_failed", "failed update data")
+	ErrUnauthorized        = NewError(KindUnauthorized, "unauthorized", "user unauthorized")
+	ErrForbidden           = NewError(KindForbidden, "forbidden", "access forbidden")
+	ErrInvalidCredentials  = NewError(KindUnauthorized, "invalid_credentials", "invalid username or password")
+	ErrUploadFile          = NewError(KindValidation, "upload_failed", "failed upload data")
+	ErrDeleteData          = NewError(KindInternal, "delete_failed", "failed delete data")
+	ErrMustHavePrimary     = NewError(KindConflict, "primary_required", "must have primary")
+	ErrNotMatch            = NewError(KindValidation, "invalid_value", "do not match")
+	ErrExists              = NewError(KindConflict, "already_exists", "already exists")
+	ErrCurrencyMismatch    = NewError(KindValidation, "currency_mismatch", "currency mismatch")
+	ErrUnsupportedCurrency = NewError(KindValidation, "unsupported_currency", "unsupported currency")
+	ErrInsufficientStock   = NewError(KindConflict, "insufficient_stock", "insufficient stock")
+	ErrInvalidMovement     = NewError(KindValidation, "invalid_movement", "invalid inventory movement")
+	ErrUnsupportedFile     = NewError(KindValidation, "unsupported_file", "unsupported file type")
+	ErrFileTooLarge        = NewError(KindValidation, "file_too_large", "file too large")
+	ErrUnsafeURL           = NewError(KindValidation, "url_not_allowed", "url not allowed")
+	ErrTimeout             = NewError(KindTimeout, "timeout", "request timed out")
+	ErrCanceled            = NewError(KindTimeout, "canceled", "request canceled")
 )
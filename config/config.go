@@ -0,0 +1,334 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile is read when CONFIG_FILE is not set. Unlike a file
+// named by CONFIG_FILE it may be missing.
+const defaultConfigFile = "config.yaml"
+
+// Config is the configuration of the whole application.
+type Config struct {
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	JWT      JWTConfig      `yaml:"jwt"`
+	Admin    AdminConfig    `yaml:"admin"`
+	SMTP     SMTPConfig     `yaml:"smtp"`
+	Storage  StorageConfig  `yaml:"storage"`
+	Jobs     JobsConfig     `yaml:"jobs"`
+	Log      LogConfig      `yaml:"log"`
+	// AppURL is the public URL of the shop, used in sitemap links.
+	AppURL string `yaml:"app_url"`
+}
+
+type ServerConfig struct {
+	Addr           string        `yaml:"addr"`
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	// ReadHeaderTimeout, ReadTimeout, WriteTimeout and IdleTimeout are the
+	// http.Server timeouts of the same name.
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout is how long a shutdown waits for requests and
+	// background work to finish.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+type DatabaseConfig struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
+	TimeZone string `yaml:"timezone"`
+	// SlowQueryThreshold is how long a query may take before it is logged
+	// as slow.
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold"`
+}
+
+type JWTConfig struct {
+	Key string `yaml:"key"`
+}
+
+type AdminConfig struct {
+	// RegistrationCode is the number a request to /register-admin must
+	// carry. Treat it like a password.
+	RegistrationCode string `yaml:"registration_code"`
+}
+
+// SMTPConfig configures outgoing mail. Mail is logged instead of sent when
+// Host is empty.
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+type StorageConfig struct {
+	// ImageDir is where uploaded product images are stored.
+	ImageDir string `yaml:"image_dir"`
+}
+
+type JobsConfig struct {
+	LowStockCheckInterval time.Duration `yaml:"low_stock_check_interval"`
+	TrashRetention        time.Duration `yaml:"trash_retention"`
+	TrashPurgeInterval    time.Duration `yaml:"trash_purge_interval"`
+}
+
+type LogConfig struct {
+	// Level is the lowest level logged: debug, info, warn or error.
+	Level string `yaml:"level"`
+}
+
+// SlogLevel is the slog.Level named by Level.
+func (c LogConfig) SlogLevel() (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(c.Level))
+	if err != nil {
+		return 0, fmt.Errorf("log level: %w", err)
+	}
+
+	return level, nil
+}
+
+// DSN is the Postgres connection string of c.
+func (c DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
+		c.Host, c.User, c.Password, c.Name, c.Port, c.SSLMode, c.TimeZone)
+}
+
+// Default returns the configuration used for everything the environment
+// and config file leave out.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:              ":3000",
+			RequestTimeout:    30 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       time.Minute,
+			WriteTimeout:      time.Minute,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Host:               "localhost",
+			Port:               "5432",
+			SSLMode:            "disable",
+			TimeZone:           "Asia/Jakarta",
+			SlowQueryThreshold: 200 * time.Millisecond,
+		},
+		SMTP: SMTPConfig{
+			Port: "587",
+		},
+		Storage: StorageConfig{
+			ImageDir: "files",
+		},
+		Jobs: JobsConfig{
+			LowStockCheckInterval: 15 * time.Minute,
+			TrashRetention:        30 * 24 * time.Hour,
+			TrashPurgeInterval:    time.Hour,
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, the YAML file named by
+// CONFIG_FILE (config.yaml when unset) and the environment, each overriding
+// the one before. A .env file, when present, fills in the environment
+// without overriding variables that are already set. The result is not
+// validated: commands that don't need every setting, like migrate, can run
+// without them.
+func Load() (Config, error) {
+	cfg := Default()
+
+	err := godotenv.Load()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, fmt.Errorf("load .env: %w", err)
+	}
+
+	path, explicit := os.LookupEnv("CONFIG_FILE")
+	if !explicit {
+		path = defaultConfigFile
+	}
+
+	err = cfg.loadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		err = nil
+	}
+	if err != nil {
+		return Config{}, err
+	}
+
+	err = cfg.loadEnv()
+	if err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// loadFile overrides cfg with the settings in the YAML file at path.
+func (cfg *Config) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+
+	err = decoder.Decode(cfg)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// loadEnv overrides cfg with the environment variables that are set.
+func (cfg *Config) loadEnv() error {
+	values := map[string]*string{
+		"HTTP_ADDR":               &cfg.Server.Addr,
+		"DB_HOST":                 &cfg.Database.Host,
+		"DB_PORT":                 &cfg.Database.Port,
+		"DB_USER":                 &cfg.Database.User,
+		"DB_PASSWORD":             &cfg.Database.Password,
+		"DB_NAME":                 &cfg.Database.Name,
+		"DB_SSLMODE":              &cfg.Database.SSLMode,
+		"DB_TIMEZONE":             &cfg.Database.TimeZone,
+		"KEY_JWT":                 &cfg.JWT.Key,
+		"ADMIN_REGISTRATION_CODE": &cfg.Admin.RegistrationCode,
+		"SMTP_HOST":               &cfg.SMTP.Host,
+		"SMTP_PORT":               &cfg.SMTP.Port,
+		"SMTP_USERNAME":           &cfg.SMTP.Username,
+		"SMTP_PASSWORD":           &cfg.SMTP.Password,
+		"MAIL_FROM":               &cfg.SMTP.From,
+		"IMAGE_DIR":               &cfg.Storage.ImageDir,
+		"APP_URL":                 &cfg.AppURL,
+		"LOG_LEVEL":               &cfg.Log.Level,
+	}
+	for name, value := range values {
+		if env, ok := os.LookupEnv(name); ok {
+			*value = env
+		}
+	}
+
+	durations := map[string]*time.Duration{
+		"REQUEST_TIMEOUT":              &cfg.Server.RequestTimeout,
+		"HTTP_READ_HEADER_TIMEOUT":     &cfg.Server.ReadHeaderTimeout,
+		"HTTP_READ_TIMEOUT":            &cfg.Server.ReadTimeout,
+		"HTTP_WRITE_TIMEOUT":           &cfg.Server.WriteTimeout,
+		"HTTP_IDLE_TIMEOUT":            &cfg.Server.IdleTimeout,
+		"SHUTDOWN_TIMEOUT":             &cfg.Server.ShutdownTimeout,
+		"DB_SLOW_QUERY_THRESHOLD":      &cfg.Database.SlowQueryThreshold,
+		"LOW_STOCK_CHECK_INTERVAL":     &cfg.Jobs.LowStockCheckInterval,
+		"PRODUCT_TRASH_RETENTION":      &cfg.Jobs.TrashRetention,
+		"PRODUCT_TRASH_PURGE_INTERVAL": &cfg.Jobs.TrashPurgeInterval,
+	}
+	for name, value := range durations {
+		env, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		d, err := time.ParseDuration(env)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		*value = d
+	}
+
+	return nil
+}
+
+// Validate reports every setting the application can't start without.
+func (cfg Config) Validate() error {
+	errs := []error{}
+
+	required := []struct {
+		name  string
+		value string
+	}{
+		{"server address (HTTP_ADDR)", cfg.Server.Addr},
+		{"database host (DB_HOST)", cfg.Database.Host},
+		{"database user (DB_USER)", cfg.Database.User},
+		{"database name (DB_NAME)", cfg.Database.Name},
+		{"JWT signing key (KEY_JWT)", cfg.JWT.Key},
+		{"admin registration code (ADMIN_REGISTRATION_CODE)", cfg.Admin.RegistrationCode},
+		{"image directory (IMAGE_DIR)", cfg.Storage.ImageDir},
+	}
+	for _, setting := range required {
+		if setting.value == "" {
+			errs = append(errs, fmt.Errorf("%s is required", setting.name))
+		}
+	}
+
+	// Clients send the code as a JSON number.
+	if cfg.Admin.RegistrationCode != "" {
+		_, err := strconv.Atoi(cfg.Admin.RegistrationCode)
+		if err != nil {
+			errs = append(errs, errors.New("admin registration code (ADMIN_REGISTRATION_CODE) must be a number"))
+		}
+	}
+
+	if cfg.SMTP.Host != "" && cfg.SMTP.From == "" {
+		errs = append(errs, errors.New("mail sender (MAIL_FROM) is required when SMTP_HOST is set"))
+	}
+
+	positive := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"request timeout (REQUEST_TIMEOUT)", cfg.Server.RequestTimeout},
+		{"read header timeout (HTTP_READ_HEADER_TIMEOUT)", cfg.Server.ReadHeaderTimeout},
+		{"read timeout (HTTP_READ_TIMEOUT)", cfg.Server.ReadTimeout},
+		{"write timeout (HTTP_WRITE_TIMEOUT)", cfg.Server.WriteTimeout},
+		{"idle timeout (HTTP_IDLE_TIMEOUT)", cfg.Server.IdleTimeout},
+		{"shutdown timeout (SHUTDOWN_TIMEOUT)", cfg.Server.ShutdownTimeout},
+		{"slow query threshold (DB_SLOW_QUERY_THRESHOLD)", cfg.Database.SlowQueryThreshold},
+		{"low stock check interval (LOW_STOCK_CHECK_INTERVAL)", cfg.Jobs.LowStockCheckInterval},
+		{"trash retention (PRODUCT_TRASH_RETENTION)", cfg.Jobs.TrashRetention},
+		{"trash purge interval (PRODUCT_TRASH_PURGE_INTERVAL)", cfg.Jobs.TrashPurgeInterval},
+	}
+	for _, setting := range positive {
+		if setting.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", setting.name))
+		}
+	}
+
+	// A handler that runs into the request timeout still has to write its
+	// error before the server stops writing.
+	if cfg.Server.WriteTimeout <= cfg.Server.RequestTimeout {
+		errs = append(errs, errors.New("write timeout (HTTP_WRITE_TIMEOUT) must be longer than the request timeout (REQUEST_TIMEOUT)"))
+	}
+
+	_, err := cfg.Log.SlogLevel()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("%w (LOG_LEVEL)", err))
+	}
+
+	err = errors.Join(errs...)
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	return nil
+}
@@ -1,9 +1,7 @@
 package config
 
 import (
-	"fmt"
-	"learn/model"
-	"os"
+	"log/slog"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -13,23 +11,16 @@ type DatabaseStruct struct {
 	DB *gorm.DB
 }
 
-func ConnectDb() *gorm.DB {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Jakarta",
-		os.Getenv("DB_HOST"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"), os.Getenv("DB_PORT"))
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{DisableForeignKeyConstraintWhenMigrating: true})
+// ConnectDb opens the database of cfg. GORM logs through log.
+func ConnectDb(cfg DatabaseConfig, log *slog.Logger) *gorm.DB {
+	db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{
+		Logger: NewGormLogger(log, cfg.SlowQueryThreshold),
+	})
 	if err != nil {
 		panic(err)
 	}
 
-	fmt.Println("Database Connected")
-
-	db.AutoMigrate(
-		model.User{},
-		model.Address{},
-		model.Product{},
-		model.ProductImage{},
-	)
+	log.Info("database connected", "host", cfg.Host, "name", cfg.Name)
 
 	return db
 }
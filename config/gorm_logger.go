@@ -0,0 +1,94 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// gormLogger writes GORM's messages to a slog.Logger. Failed queries are
+// errors, queries slower than SlowThreshold are warnings and every other
+// query is logged at debug level.
+type gormLogger struct {
+	Logger        *slog.Logger
+	Level         logger.LogLevel
+	SlowThreshold time.Duration
+}
+
+var _ gorm.ParamsFilter = (*gormLogger)(nil)
+
+// NewGormLogger returns a GORM logger that writes to log and warns about
+// queries that take longer than slowThreshold.
+func NewGormLogger(log *slog.Logger, slowThreshold time.Duration) logger.Interface {
+	return &gormLogger{
+		Logger:        log,
+		Level:         logger.Info,
+		SlowThreshold: slowThreshold,
+	}
+}
+
+// LogMode implements logger.Interface
+func (l *gormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	mode := *l
+	mode.Level = level
+	return &mode
+}
+
+// Info implements logger.Interface
+func (l *gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.Level >= logger.Info {
+		l.Logger.InfoContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Warn implements logger.Interface
+func (l *gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.Level >= logger.Warn {
+		l.Logger.WarnContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Error implements logger.Interface
+func (l *gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.Level >= logger.Error {
+		l.Logger.ErrorContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// ParamsFilter implements gorm.ParamsFilter. Query parameters hold
+// emails, phone numbers and password hashes, so logged SQL keeps its
+// placeholders unless debug logging is on.
+func (l *gormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.Logger.Enabled(ctx, slog.LevelDebug) {
+		return sql, params
+	}
+
+	return sql, nil
+}
+
+// Trace implements logger.Interface. Record not found is how lookups report
+// a missing row, so it is not logged as a failure.
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.Level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.Level >= logger.Error:
+		sql, rows := fc()
+		l.Logger.ErrorContext(ctx, "query failed", "sql", sql, "rows", rows, "duration", elapsed, "error", err)
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold && l.Level >= logger.Warn:
+		sql, rows := fc()
+		l.Logger.WarnContext(ctx, "slow query", "sql", sql, "rows", rows, "duration", elapsed, "threshold", l.SlowThreshold)
+	case l.Level >= logger.Info && l.Logger.Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		l.Logger.DebugContext(ctx, "query", "sql", sql, "rows", rows, "duration", elapsed)
+	}
+}
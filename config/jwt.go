@@ -1,21 +1,70 @@
 package config
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
-	"os"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var mySigningKey = []byte(os.Getenv("KEY_JWT"))
+// Claims is what a token says about its user. ID (jti) identifies the token
+// itself.
+type Claims struct {
+	UserId      int      `json:"user_id"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Permissions a token can grant. Handlers check them on the routes they
+// guard.
+const (
+	PermProductsWrite      = "products:write"
+	PermInventoryWrite     = "inventory:write"
+	PermWarehousesWrite    = "warehouses:write"
+	PermTaxWrite           = "tax:write"
+	PermExchangeRatesWrite = "exchange_rates:write"
+	PermAuditRead          = "audit:read"
+	PermAddressesWrite     = "addresses:write"
+	PermNotificationsRead  = "notifications:read"
+)
+
+// rolePermissions lists the permissions a token grants for each role.
+var rolePermissions = map[string][]string{
+	"admin": {PermProductsWrite, PermInventoryWrite, PermWarehousesWrite, PermTaxWrite, PermExchangeRatesWrite, PermAuditRead, PermAddressesWrite, PermNotificationsRead},
+	"user":  {PermAddressesWrite, PermNotificationsRead},
+}
+
+// Tokens creates and verifies access tokens signed with one key.
+type Tokens struct {
+	key []byte
+}
 
-func CreateToken(userId int, role string) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": userId,
-		"role":    role,
+func NewTokens(key string) *Tokens {
+	return &Tokens{
+		key: []byte(key),
+	}
+}
+
+func (tokens *Tokens) Create(userId int, role string) (string, error) {
+	tokenId, err := newTokenId()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		UserId:      userId,
+		Role:        role,
+		Permissions: rolePermissions[role],
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:       tokenId,
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+		},
 	})
 
-	tokenString, err := token.SignedString(mySigningKey)
+	tokenString, err := token.SignedString(tokens.key)
 	if err != nil {
 		return "", err
 	}
@@ -23,26 +72,42 @@ func CreateToken(userId int, role string) (string, error) {
 	return tokenString, nil
 }
 
-func Parse(tokenString string) (any, error) {
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+func (tokens *Tokens) Parse(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
 		// Don't forget to validate the alg is what you expect:
 		if method, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("signing method invalid")
 		} else if method != jwt.SigningMethodHS256 {
 			return nil, fmt.Errorf("signing method invalid")
 		}
-		return mySigningKey, nil
+		return tokens.key, nil
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("unauthorized token")
+		return nil, fmt.Errorf("unauthorized token: %w", err)
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-
-	if !ok || !token.Valid {
+	if !token.Valid || claims.UserId <= 0 || claims.Role == "" {
 		return nil, fmt.Errorf("unauthorized validation")
 	}
 
+	// Tokens issued before permissions were added get those of their role.
+	if claims.Permissions == nil {
+		claims.Permissions = rolePermissions[claims.Role]
+	}
+
 	return claims, nil
 }
+
+func newTokenId() (string, error) {
+	id := make([]byte, 16)
+
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,310 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationLock is the advisory lock key that keeps two migrators from
+// applying the same version at once.
+const migrationLock = 7_247_001
+
+// migrationFile matches NNNN_name.up.sql and NNNN_name.down.sql.
+var migrationFile = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one versioned schema change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a migration and when it was applied. AppliedAt is nil
+// while the migration is pending.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// schemaMigration is a row of schema_migrations, which records the applied
+// versions.
+type schemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// Migrator applies and reverts the migrations of a database.
+type Migrator struct {
+	DB         *gorm.DB
+	Migrations []Migration
+}
+
+// NewMigrator returns a Migrator for the migrations in fsys.
+func NewMigrator(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		DB:         db,
+		Migrations: migrations,
+	}, nil
+}
+
+// LoadMigrations reads the migrations in fsys sorted by version. Every
+// version needs both an up and a down file.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, migration.Name, match[2])
+		}
+
+		sql, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read migration %s: %w", entry.Name(), err)
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(sql)
+		} else {
+			migration.Down = string(sql)
+		}
+	}
+
+	migrations := []Migration{}
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Status lists every migration with the time it was applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(m.DB.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []MigrationStatus{}
+	for _, migration := range m.Migrations {
+		status := MigrationStatus{Migration: migration}
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Pending returns the migrations that have not been applied yet.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []Migration{}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, status.Migration)
+		}
+	}
+
+	return pending, nil
+}
+
+// Up applies the pending migrations in version order and returns them.
+// Each migration runs in its own transaction, so a failing one leaves the
+// earlier ones applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	for _, migration := range pending {
+		err = m.run(ctx, migration, true)
+		if err != nil {
+			return done, err
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// them.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+		if statuses[i].AppliedAt == nil {
+			continue
+		}
+
+		err = m.run(ctx, statuses[i].Migration, false)
+		if err != nil {
+			return done, err
+		}
+
+		done = append(done, statuses[i].Migration)
+	}
+
+	return done, nil
+}
+
+// run applies or reverts migration and records it in schema_migrations in
+// one transaction. A migration another migrator already handled is skipped.
+func (m *Migrator) run(ctx context.Context, migration Migration, up bool) error {
+	err := m.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLock).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version bigint PRIMARY KEY, name text NOT NULL, applied_at timestamptz NOT NULL)").Error
+		if err != nil {
+			return err
+		}
+
+		var count int64
+		err = tx.Model(&schemaMigration{}).Where("version = ?", migration.Version).Count(&count).Error
+		if err != nil {
+			return err
+		}
+
+		if up == (count > 0) {
+			return nil
+		}
+
+		if up {
+			err = tx.Exec(migration.Up).Error
+			if err != nil {
+				return err
+			}
+
+			return tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		}
+
+		err = tx.Exec(migration.Down).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("version = ?", migration.Version).Delete(&schemaMigration{}).Error
+	})
+	if err != nil {
+		direction := "up"
+		if !up {
+			direction = "down"
+		}
+
+		return fmt.Errorf("migration %d_%s %s: %w", migration.Version, migration.Name, direction, err)
+	}
+
+	return nil
+}
+
+// applied returns the rows of schema_migrations by version. Nothing is
+// applied before the first run creates the table.
+func (m *Migrator) applied(db *gorm.DB) (map[int]schemaMigration, error) {
+	applied := map[int]schemaMigration{}
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		return applied, nil
+	}
+
+	rows := []schemaMigration{}
+	err := db.Order("version").Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("schema_migrations: %w", err)
+	}
+
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+
+	return applied, nil
+}
+
+// CreateMigration writes up and down files for a new migration named name
+// in dir, numbered after the newest migration there, and returns their
+// paths.
+func CreateMigration(dir string, name string) (string, string, error) {
+	name = strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", "", errors.New("migration name is empty")
+	}
+
+	migrations, err := LoadMigrations(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+
+	version := 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	base := fmt.Sprintf("%04d_%s", version, name)
+	up, down := filepath.Join(dir, base+".up.sql"), filepath.Join(dir, base+".down.sql")
+
+	files := map[string]string{
+		up:   "-- " + base + ": write the schema change here.\n",
+		down: "-- " + base + ": revert " + base + ".up.sql here.\n",
+	}
+	for path, content := range files {
+		err = os.WriteFile(path, []byte(content), 0o644)
+		if err != nil {
+			return "", "", fmt.Errorf("create migration: %w", err)
+		}
+	}
+
+	return up, down, nil
+}
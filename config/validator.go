@@ -0,0 +1,266 @@
+package config
+
+import (
+	"errors"
+	"learn/common"
+	"learn/model"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	idTranslations "github.com/go-playground/validator/v10/translations/id"
+	"golang.org/x/text/language"
+)
+
+// Validator validates requests and translates the failures into field
+// errors in the client's language. English is the fallback.
+type Validator struct {
+	*validator.Validate
+	translator *ut.UniversalTranslator
+}
+
+var (
+	// phonePattern accepts international numbers with a leading + and local
+	// numbers with a leading 0, after spaces and dashes are removed.
+	phonePattern = regexp.MustCompile(`^(\+[1-9][0-9]{7,14}|0[0-9]{8,13})$`)
+	// postalCodePattern accepts five digit Indonesian postal codes.
+	postalCodePattern = regexp.MustCompile(`^[1-9][0-9]{4}$`)
+
+	phoneSeparators = strings.NewReplacer(" ", "", "-", "")
+)
+
+const minPasswordLength = 8
+
+// customTranslations holds the messages for the custom validators and for
+// the summary of a failed validation, per locale.
+var customTranslations = map[string]map[string]string{
+	"en": {
+		"validation_failed": "request validation failed",
+		"phone":             "{0} must be a valid phone number",
+		"postal_code":       "{0} must be a valid 5 digit postal code",
+		"strong_password":   "{0} must be at least 8 characters with upper and lower case letters, a number and a symbol",
+		"barcode":           "{0} must be an EAN-8, UPC-A or EAN-13 code with a valid check digit",
+		"slug":              "{0} must contain a letter",
+	},
+	"id": {
+		"validation_failed": "validasi permintaan gagal",
+		"phone":             "{0} harus berupa nomor telepon yang valid",
+		"postal_code":       "{0} harus berupa kode pos 5 digit yang valid",
+		"strong_password":   "{0} minimal 8 karakter dengan huruf besar, huruf kecil, angka dan simbol",
+		"barcode":           "{0} harus berupa kode EAN-8, UPC-A atau EAN-13 dengan digit pemeriksa yang valid",
+		"slug":              "{0} harus mengandung huruf",
+	},
+}
+
+// NewValidator returns a validator that reports fields by their JSON (or
+// form) name and knows the phone, postal_code, strong_password, barcode and
+// slug rules.
+func NewValidator() (*Validator, error) {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.RegisterTagNameFunc(fieldName)
+
+	validations := map[string]validator.Func{
+		"phone":           validatePhone,
+		"postal_code":     validatePostalCode,
+		"strong_password": validateStrongPassword,
+		"barcode":         validateBarcode,
+		"slug":            validateSlug,
+	}
+	for tag, fn := range validations {
+		err := validate.RegisterValidation(tag, fn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	validate.RegisterStructValidation(validateProductPrice, model.ProductReq{})
+
+	english := en.New()
+	translator := ut.New(english, english, id.New())
+
+	enTrans, _ := translator.GetTranslator("en")
+	err := enTranslations.RegisterDefaultTranslations(validate, enTrans)
+	if err != nil {
+		return nil, err
+	}
+
+	idTrans, _ := translator.GetTranslator("id")
+	err = idTranslations.RegisterDefaultTranslations(validate, idTrans)
+	if err != nil {
+		return nil, err
+	}
+
+	for locale, messages := range customTranslations {
+		trans, _ := translator.GetTranslator(locale)
+
+		for tag, message := range messages {
+			err = registerTranslation(validate, trans, tag, message)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &Validator{Validate: validate, translator: translator}, nil
+}
+
+// Fields translates the failures in err into field errors in the language
+// picked from acceptLanguage. It returns nil when err holds none.
+func (v *Validator) Fields(err error, acceptLanguage string) []common.FieldError {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil
+	}
+
+	trans := v.Translator(acceptLanguage)
+
+	fields := make([]common.FieldError, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		fields = append(fields, common.FieldError{
+			Field:   fieldPath(fieldError),
+			Rule:    fieldError.Tag(),
+			Message: fieldError.Translate(trans),
+		})
+	}
+
+	return fields
+}
+
+// Invalid returns err from Struct as a validation error carrying the
+// translated field errors. Errors that are not validation failures, such
+// as passing a non-struct, are returned unchanged.
+func (v *Validator) Invalid(err error, acceptLanguage string) error {
+	fields := v.Fields(err, acceptLanguage)
+	if fields == nil {
+		return err
+	}
+
+	message, _ := v.Translator(acceptLanguage).T("validation_failed")
+
+	return &common.Error{
+		Kind:    common.KindValidation,
+		Code:    "validation_failed",
+		Message: message,
+		Fields:  fields,
+		Err:     err,
+	}
+}
+
+// Translator returns the translator for the best supported language in an
+// Accept-Language header.
+func (v *Validator) Translator(acceptLanguage string) ut.Translator {
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+
+	locales := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		base, _ := tag.Base()
+		locales = append(locales, base.String())
+	}
+
+	trans, _ := v.translator.FindTranslator(locales...)
+
+	return trans
+}
+
+func registerTranslation(validate *validator.Validate, trans ut.Translator, tag string, message string) error {
+	register := func(trans ut.Translator) error {
+		return trans.Add(tag, message, true)
+	}
+
+	translate := func(trans ut.Translator, fieldError validator.FieldError) string {
+		text, err := trans.T(tag, fieldError.Field())
+		if err != nil {
+			return fieldError.Error()
+		}
+
+		return text
+	}
+
+	return validate.RegisterTranslation(tag, trans, register, translate)
+}
+
+// fieldName is the name a field is reported by: its JSON name, else its
+// form name, else the Go name.
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		name := strings.SplitN(field.Tag.Get(key), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+
+		if name != "" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+// fieldPath is the field's path below the request struct, such as
+// "price.amount", so nested fields stay unambiguous.
+func fieldPath(fieldError validator.FieldError) string {
+	namespace := fieldError.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+
+	return fieldError.Field()
+}
+
+func validatePhone(fl validator.FieldLevel) bool {
+	return phonePattern.MatchString(phoneSeparators.Replace(fl.Field().String()))
+}
+
+func validatePostalCode(fl validator.FieldLevel) bool {
+	return postalCodePattern.MatchString(fl.Field().String())
+}
+
+func validateStrongPassword(fl validator.FieldLevel) bool {
+	password := fl.Field().String()
+	if len([]rune(password)) < minPasswordLength {
+		return false
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	return upper && lower && digit && symbol
+}
+
+func validateBarcode(fl validator.FieldLevel) bool {
+	return model.ValidBarcode(fl.Field().String())
+}
+
+// validateSlug rejects a slug without letters. An all digit slug would be
+// shadowed by the product id route, and a client asking for "1984" should
+// hear so rather than silently get "product-1984".
+func validateSlug(fl validator.FieldLevel) bool {
+	return model.ValidSlug(fl.Field().String())
+}
+
+// validateProductPrice rejects a free product. Money itself only rejects a
+// negative amount, and a price without an amount still has a currency once
+// decoded, so required on the price can't catch it.
+func validateProductPrice(sl validator.StructLevel) {
+	req := sl.Current().Interface().(model.ProductReq)
+	if req.Price.Amount == 0 {
+		sl.ReportError(req.Price.Amount, "price.amount", "Amount", "gt", "0")
+	}
+}
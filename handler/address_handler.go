@@ -2,15 +2,14 @@ package handler
 
 import (
 	"encoding/json"
-	"errors"
+	"learn/common"
+	"learn/config"
 	"learn/model"
 	"learn/service"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-playground/validator/v10"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 type AddressHandler interface {
@@ -22,12 +21,14 @@ type AddressHandler interface {
 
 type addresshandler struct {
 	Service  service.AddressService
-	Validate *validator.Validate
+	Audit    service.AuditService
+	Validate *config.Validator
 }
 
-func NewAddressHandler(srv service.AddressService, validate *validator.Validate) AddressHandler {
+func NewAddressHandler(srv service.AddressService, audit service.AuditService, validate *config.Validator) AddressHandler {
 	return &addresshandler{
 		Service:  srv,
+		Audit:    audit,
 		Validate: validate,
 	}
 }
@@ -42,33 +43,44 @@ func (h *addresshandler) AddAddress(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&req)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, err)
+		WriteError(w, common.Invalid(err))
 		return
 	}
 
 	err = h.Validate.Struct(&req)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, err)
+		WriteError(w, h.Validate.Invalid(err, r.Header.Get("Accept-Language")))
 		return
 	}
 
-	userInfo := r.Context().Value("userInfo").(jwt.MapClaims)
-	user := userInfo["user_id"].(float64)
-	id := int(user)
+	principal, err := PrincipalFrom(r.Context())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	err = principal.Require(config.PermAddressesWrite)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	id := principal.UserId
 
 	if intUserId != id {
-		WriteErrorResponse(w, http.StatusInternalServerError, errors.New("user unauthorized"))
+		WriteError(w, common.ErrForbidden)
 		return
 	}
 
 	req.UserId = id
 
-	resAddress, err := h.Service.AddAddress(req, id)
+	resAddress, err := h.Service.AddAddress(r.Context(), req, id)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		WriteError(w, err)
 		return
 	}
 
+	recordAudit(h.Audit, r, id, model.AuditCreate, model.AuditEntityAddress, resAddress.Id, nil, resAddress)
+
 	WriteDataResponse(w, http.StatusOK, resAddress)
 }
 
@@ -77,18 +89,21 @@ func (h *addresshandler) GetAddresses(w http.ResponseWriter, r *http.Request) {
 	stringUserId := chi.URLParam(r, "user-id")
 	intUserId, _ := strconv.Atoi(stringUserId)
 
-	userInfo := r.Context().Value("userInfo").(jwt.MapClaims)
-	user := userInfo["user_id"].(float64)
-	id := int(user)
+	principal, err := PrincipalFrom(r.Context())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	id := principal.UserId
 
 	if intUserId != id {
-		WriteErrorResponse(w, http.StatusInternalServerError, errors.New("user unauthorized"))
+		WriteError(w, common.ErrForbidden)
 		return
 	}
 
-	userAddresses, err := h.Service.GetAddresses(id)
+	userAddresses, err := h.Service.GetAddresses(r.Context(), id)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		WriteError(w, err)
 		return
 	}
 
@@ -108,33 +123,50 @@ func (h *addresshandler) UpdateAddress(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&req)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, err)
+		WriteError(w, common.Invalid(err))
 		return
 	}
 
 	err = h.Validate.Struct(&req)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, err)
+		WriteError(w, h.Validate.Invalid(err, r.Header.Get("Accept-Language")))
 		return
 	}
 
-	userInfo := r.Context().Value("userInfo").(jwt.MapClaims)
-	user := userInfo["user_id"].(float64)
-	id := int(user)
+	principal, err := PrincipalFrom(r.Context())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	err = principal.Require(config.PermAddressesWrite)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	id := principal.UserId
 
 	if addressUserIdInt != id {
-		WriteErrorResponse(w, http.StatusInternalServerError, errors.New("user unauthorized"))
+		WriteError(w, common.ErrForbidden)
 		return
 	}
 
 	req.UserId = id
 
-	addressRes, err := h.Service.UpdateAddress(req, addressIdInt)
+	before, err := h.Service.FindAddressById(r.Context(), addressIdInt, id)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, err)
+		WriteError(w, err)
 		return
 	}
 
+	addressRes, err := h.Service.UpdateAddress(r.Context(), req, addressIdInt)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	recordAudit(h.Audit, r, id, model.AuditUpdate, model.AuditEntityAddress, addressIdInt, before, addressRes)
+
 	WriteDataResponse(w, http.StatusOK, addressRes)
 }
 
@@ -146,20 +178,37 @@ func (h *addresshandler) DeleteAddress(w http.ResponseWriter, r *http.Request) {
 	stringAddressId := chi.URLParam(r, "address-id")
 	intAddressId, _ := strconv.Atoi(stringAddressId)
 
-	userInfo := r.Context().Value("userInfo").(jwt.MapClaims)
-	user := userInfo["user_id"].(float64)
-	id := int(user)
+	principal, err := PrincipalFrom(r.Context())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	err = principal.Require(config.PermAddressesWrite)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	id := principal.UserId
 
 	if intUserId != id {
-		WriteErrorResponse(w, http.StatusInternalServerError, errors.New("user unauthorized"))
+		WriteError(w, common.ErrForbidden)
+		return
+	}
+
+	before, err := h.Service.FindAddressById(r.Context(), intAddressId, id)
+	if err != nil {
+		WriteError(w, err)
 		return
 	}
 
-	response, err := h.Service.DeleteAddress(intAddressId)
+	response, err := h.Service.DeleteAddress(r.Context(), intAddressId, id)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		WriteError(w, err)
 		return
 	}
 
+	recordAudit(h.Audit, r, id, model.AuditDelete, model.AuditEntityAddress, intAddressId, before, nil)
+
 	WriteDataResponse(w, http.StatusOK, response)
 }
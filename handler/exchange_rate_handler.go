@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"learn/common"
+	"learn/config"
+	"learn/model"
+	"learn/service"
+	"net/http"
+)
+
+type ExchangeRateHandler interface {
+	// ADMIN
+	SetExchangeRate(w http.ResponseWriter, r *http.Request)
+	ImportExchangeRates(w http.ResponseWriter, r *http.Request)
+	FindAllExchangeRate(w http.ResponseWriter, r *http.Request)
+}
+
+type exchangeRateHandler struct {
+	Service  service.ExchangeRateService
+	Validate *config.Validator
+}
+
+func NewExchangeRateHandler(service service.ExchangeRateService, validate *config.Validator) ExchangeRateHandler {
+	return &exchangeRateHandler{
+		Service:  service,
+		Validate: validate,
+	}
+}
+
+// SetExchangeRate implements ExchangeRateHandler
+func (h *exchangeRateHandler) SetExchangeRate(w http.ResponseWriter, r *http.Request) {
+	var req model.ExchangeRateReq
+
+	_, err := permittedPrincipal(r, config.PermExchangeRatesWrite)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&req)
+	if err != nil {
+		WriteError(w, common.Invalid(err))
+		return
+	}
+
+	err = h.Validate.Struct(&req)
+	if err != nil {
+		WriteError(w, h.Validate.Invalid(err, r.Header.Get("Accept-Language")))
+		return
+	}
+
+	response, err := h.Service.SetExchangeRate(req)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
+
+// ImportExchangeRates implements ExchangeRateHandler
+func (h *exchangeRateHandler) ImportExchangeRates(w http.ResponseWriter, r *http.Request) {
+
+	_, err := permittedPrincipal(r, config.PermExchangeRatesWrite)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	uploadedFile, _, err := r.FormFile("file-rates")
+	if err != nil {
+		WriteError(w, common.Invalid(err))
+		return
+	}
+	defer uploadedFile.Close()
+
+	response, err := h.Service.ImportExchangeRates(uploadedFile)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
+
+// FindAllExchangeRate implements ExchangeRateHandler
+func (h *exchangeRateHandler) FindAllExchangeRate(w http.ResponseWriter, r *http.Request) {
+
+	_, err := adminPrincipal(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	response, err := h.Service.FindAllExchangeRate()
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"learn/model"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// healthCheckTimeout bounds each readiness check, so a hanging dependency
+// fails the probe instead of stalling it.
+const healthCheckTimeout = 5 * time.Second
+
+// Health statuses
+const (
+	HealthOk          = "ok"
+	HealthUnavailable = "unavailable"
+)
+
+type HealthHandler interface {
+	// PUBLIC
+	Live(w http.ResponseWriter, r *http.Request)
+	Ready(w http.ResponseWriter, r *http.Request)
+}
+
+// HealthCheck is a dependency the application can't serve requests without.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type healthHandler struct {
+	Checks []HealthCheck
+}
+
+func NewHealthHandler(checks ...HealthCheck) HealthHandler {
+	return &healthHandler{
+		Checks: checks,
+	}
+}
+
+// Live implements HealthHandler. The process is alive as long as it can
+// answer, so no dependency is checked.
+func (h *healthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	WriteDataResponse(w, http.StatusOK, model.HealthRes{Status: HealthOk})
+}
+
+// Ready implements HealthHandler. It answers 503 when any check fails; the
+// reason is logged rather than shown, since the endpoint is public.
+func (h *healthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	res := model.HealthRes{
+		Status: HealthOk,
+		Checks: map[string]string{},
+	}
+
+	for _, check := range h.Checks {
+		ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+		err := check.Check(ctx)
+		cancel()
+
+		if err != nil {
+			slog.WarnContext(r.Context(), "readiness check failed", "check", check.Name, "error", err)
+			res.Checks[check.Name] = HealthUnavailable
+			res.Status = HealthUnavailable
+			continue
+		}
+
+		res.Checks[check.Name] = HealthOk
+	}
+
+	status := http.StatusOK
+	if res.Status != HealthOk {
+		status = http.StatusServiceUnavailable
+	}
+
+	WriteDataResponse(w, status, res)
+}
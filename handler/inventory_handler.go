@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"learn/common"
+	"learn/config"
+	"learn/model"
+	"learn/service"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type InventoryHandler interface {
+	// ADMIN
+	RecordMovement(w http.ResponseWriter, r *http.Request)
+	FindMovementsByProductId(w http.ResponseWriter, r *http.Request)
+	Reconcile(w http.ResponseWriter, r *http.Request)
+}
+
+type inventoryHandler struct {
+	Service  service.InventoryService
+	Audit    service.AuditService
+	Validate *config.Validator
+}
+
+func NewInventoryHandler(service service.InventoryService, audit service.AuditService, validate *config.Validator) InventoryHandler {
+	return &inventoryHandler{
+		Service:  service,
+		Audit:    audit,
+		Validate: validate,
+	}
+}
+
+// RecordMovement implements InventoryHandler
+func (h *inventoryHandler) RecordMovement(w http.ResponseWriter, r *http.Request) {
+	var req model.InventoryMovementReq
+
+	productId := chi.URLParam(r, "product-id")
+	productIdInt, _ := strconv.Atoi(productId)
+
+	principal, err := permittedPrincipal(r, config.PermInventoryWrite)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	id := principal.UserId
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&req)
+	if err != nil {
+		WriteError(w, common.Invalid(err))
+		return
+	}
+
+	err = h.Validate.Struct(&req)
+	if err != nil {
+		WriteError(w, h.Validate.Invalid(err, r.Header.Get("Accept-Language")))
+		return
+	}
+
+	response, err := h.Service.RecordMovement(req, productIdInt, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	recordAudit(h.Audit, r, id, model.AuditCreate, model.AuditEntityInventoryMovement, response.Id, nil, response)
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
+
+// FindMovementsByProductId implements InventoryHandler
+func (h *inventoryHandler) FindMovementsByProductId(w http.ResponseWriter, r *http.Request) {
+	productId := chi.URLParam(r, "product-id")
+	productIdInt, _ := strconv.Atoi(productId)
+
+	_, err := adminPrincipal(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	response, err := h.Service.FindMovementsByProductId(productIdInt)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
+
+// Reconcile implements InventoryHandler
+func (h *inventoryHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+
+	_, err := adminPrincipal(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	response, err := h.Service.Reconcile()
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
@@ -2,17 +2,50 @@ package handler
 
 import (
 	"encoding/json"
+	"encoding/xml"
+	"learn/common"
 	"net/http"
 )
 
 type messageError struct {
-	Message string `json:"message"`
+	Code    string              `json:"code"`
+	Message string              `json:"message"`
+	Fields  []common.FieldError `json:"fields,omitempty"`
 }
 
-func WriteErrorResponse(w http.ResponseWriter, code int, err error) {
+// errorStatus maps each error kind to its HTTP status.
+var errorStatus = map[common.Kind]int{
+	common.KindNotFound:     http.StatusNotFound,
+	common.KindConflict:     http.StatusConflict,
+	common.KindValidation:   http.StatusBadRequest,
+	common.KindUnauthorized: http.StatusUnauthorized,
+	common.KindForbidden:    http.StatusForbidden,
+	common.KindInternal:     http.StatusInternalServerError,
+	common.KindTimeout:      http.StatusGatewayTimeout,
+}
+
+// WriteError writes err with the status for its kind. Clients only see the
+// domain error's code and message; internal errors are logged in full and
+// answered with a generic message.
+func WriteError(w http.ResponseWriter, err error) {
+	domainErr := common.AsError(err)
+
+	status, ok := errorStatus[domainErr.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	if status == http.StatusInternalServerError {
+		logError(w, err)
+	}
+
+	writeErrorBody(w, status, messageError{Code: domainErr.Code, Message: domainErr.Message, Fields: domainErr.Fields})
+}
+
+func writeErrorBody(w http.ResponseWriter, code int, body messageError) {
 	w.Header().Add("content-type", "application/json")
 	w.WriteHeader(code)
-	err = json.NewEncoder(w).Encode(messageError{Message: err.Error()})
+	err := json.NewEncoder(w).Encode(body)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -28,3 +61,17 @@ func WriteDataResponse(w http.ResponseWriter, code int, payload interface{}) {
 		return
 	}
 }
+
+func WriteXMLResponse(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Add("content-type", "application/xml")
+	w.WriteHeader(code)
+	_, err := w.Write([]byte(xml.Header))
+	if err != nil {
+		return
+	}
+	err = xml.NewEncoder(w).Encode(payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
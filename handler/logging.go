@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RequestIdHeader carries the id of a request in both directions.
+const RequestIdHeader = "X-Request-ID"
+
+// validRequestId is what an id from the client has to look like to be
+// reused; anything else is replaced so it can't forge log lines.
+var validRequestId = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// requestLog is what log lines written during a request say about it. Auth
+// fills in UserId once the caller is known.
+type requestLog struct {
+	Id     string
+	UserId int
+}
+
+// requestLogKey is the context key RequestID stores the requestLog under.
+type requestLogKey struct{}
+
+func requestLogFrom(ctx context.Context) *requestLog {
+	info, _ := ctx.Value(requestLogKey{}).(*requestLog)
+	return info
+}
+
+// RequestIdFrom returns the id RequestID gave the request, or "" outside a
+// request.
+func RequestIdFrom(ctx context.Context) string {
+	info := requestLogFrom(ctx)
+	if info == nil {
+		return ""
+	}
+
+	return info.Id
+}
+
+// RequestID gives every request an id: the client's X-Request-ID when it
+// sends a usable one, else a random one. The id is sent back in the same
+// header and added to every log line written with the request's context.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIdHeader)
+		if !validRequestId.MatchString(id) {
+			id = newRequestId()
+		}
+
+		w.Header().Set(RequestIdHeader, id)
+		ctx := context.WithValue(r.Context(), requestLogKey{}, &requestLog{Id: id})
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func newRequestId() string {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// setLogUserId adds the caller's id to the log lines of the request.
+func setLogUserId(ctx context.Context, userId int) {
+	info := requestLogFrom(ctx)
+	if info != nil {
+		info.UserId = userId
+	}
+}
+
+// logResponseWriter remembers what was written for the request log.
+type logResponseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+	err    error
+}
+
+func (w *logResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *logResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *logResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Logger writes one line for every request once it is answered. Requests
+// answered with an internal error are logged at error level together with
+// the error WriteError was given.
+func Logger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		lw := &logResponseWriter{ResponseWriter: w}
+
+		next.ServeHTTP(lw, r)
+
+		if lw.status == 0 {
+			lw.status = http.StatusOK
+		}
+
+		level := slog.LevelInfo
+		if lw.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", lw.status),
+			slog.Int("bytes", lw.bytes),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote_addr", r.RemoteAddr),
+		}
+		if lw.err != nil {
+			attrs = append(attrs, errorAttrs(lw.err)...)
+		}
+
+		slog.LogAttrs(r.Context(), level, "request", attrs...)
+	})
+}
+
+// logError hands err to the request log when w comes from Logger, and logs
+// it on its own otherwise.
+func logError(w http.ResponseWriter, err error) {
+	lw, ok := w.(*logResponseWriter)
+	if ok {
+		lw.err = err
+		return
+	}
+
+	slog.LogAttrs(context.Background(), slog.LevelError, "internal error", errorAttrs(err)...)
+}
+
+// errorAttrs describe err by its message and the types of the errors it
+// wraps, outermost first, so the cause can be told apart from the service
+// and repository layers wrapped around it.
+func errorAttrs(err error) []slog.Attr {
+	return []slog.Attr{
+		slog.String("error", err.Error()),
+		slog.Any("error_chain", errorChain(err)),
+	}
+}
+
+func errorChain(err error) []string {
+	chain := []string{}
+	for err != nil {
+		chain = append(chain, fmt.Sprintf("%T", err))
+
+		joined, ok := err.(interface{ Unwrap() []error })
+		if ok {
+			for _, inner := range joined.Unwrap() {
+				chain = append(chain, errorChain(inner)...)
+			}
+			break
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	return chain
+}
+
+// logHandler adds the request id, user id and route pattern of the request
+// a line is logged for.
+type logHandler struct {
+	slog.Handler
+}
+
+// NewLogHandler wraps h so lines logged with a request's context say which
+// request they belong to.
+func NewLogHandler(h slog.Handler) slog.Handler {
+	return &logHandler{Handler: h}
+}
+
+// Handle implements slog.Handler
+func (h *logHandler) Handle(ctx context.Context, record slog.Record) error {
+	info := requestLogFrom(ctx)
+	if info != nil {
+		record.AddAttrs(slog.String("request_id", info.Id))
+		if info.UserId != 0 {
+			record.AddAttrs(slog.Int("user_id", info.UserId))
+		}
+	}
+
+	routeCtx := chi.RouteContext(ctx)
+	if routeCtx != nil && routeCtx.RoutePattern() != "" {
+		record.AddAttrs(slog.String("route", routeCtx.RoutePattern()))
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &logHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup implements slog.Handler
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	return &logHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -2,20 +2,30 @@ package handler
 
 import (
 	"context"
-	"errors"
+	"fmt"
+	"learn/common"
 	"learn/config"
 	"net/http"
 	"strings"
+	"time"
 )
 
-var errNoAuthHeaderIncluded = errors.New("no authorization header included")
+var errNoAuthHeaderIncluded = common.NewError(common.KindUnauthorized, "missing_token", "no authorization header included")
 
-func Auth(next http.HandlerFunc) http.HandlerFunc {
+// Auth returns a wrapper that lets requests through to next only with a
+// bearer token tokens accepts, and puts its Principal in the context.
+func Auth(tokens *config.Tokens) func(next http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return authenticate(tokens, next)
+	}
+}
+
+func authenticate(tokens *config.Tokens, next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		accessToken := r.Header.Get("Authorization")
 
 		if accessToken == "" {
-			WriteErrorResponse(w, http.StatusUnauthorized, errNoAuthHeaderIncluded)
+			WriteError(w, errNoAuthHeaderIncluded)
 			return
 		}
 
@@ -25,15 +35,35 @@ func Auth(next http.HandlerFunc) http.HandlerFunc {
 			token = arrayToken[1]
 		}
 
-		claims, err := config.Parse(token)
+		claims, err := tokens.Parse(token)
 		if err != nil {
-			WriteErrorResponse(w, http.StatusUnauthorized, err)
+			WriteError(w, fmt.Errorf("Parse call failed: %v : %w", err, common.ErrUnauthorized))
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), "userInfo", claims)
-		r = r.WithContext(ctx)
+		principal := Principal{
+			UserId:      claims.UserId,
+			Role:        claims.Role,
+			Permissions: claims.Permissions,
+			TokenId:     claims.ID,
+		}
+		r = r.WithContext(WithPrincipal(r.Context(), principal))
+		setLogUserId(r.Context(), principal.UserId)
 
 		next.ServeHTTP(w, r)
 	})
 }
+
+// Timeout gives every request a deadline of d. Handlers pass r.Context()
+// down to the database, so queries stop once the deadline passes or the
+// client goes away.
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
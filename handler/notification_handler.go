@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"learn/config"
+	"learn/service"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type NotificationHandler interface {
+	// USER
+	SubscribeBackInStock(w http.ResponseWriter, r *http.Request)
+	FindNotifications(w http.ResponseWriter, r *http.Request)
+	MarkNotificationRead(w http.ResponseWriter, r *http.Request)
+}
+
+type notificationHandler struct {
+	Service service.NotificationService
+}
+
+func NewNotificationHandler(service service.NotificationService) NotificationHandler {
+	return &notificationHandler{
+		Service: service,
+	}
+}
+
+// SubscribeBackInStock implements NotificationHandler
+func (h *notificationHandler) SubscribeBackInStock(w http.ResponseWriter, r *http.Request) {
+	productId := chi.URLParam(r, "product-id")
+	productIdInt, _ := strconv.Atoi(productId)
+
+	principal, err := PrincipalFrom(r.Context())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	id := principal.UserId
+
+	response, err := h.Service.SubscribeBackInStock(r.Context(), productIdInt, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
+
+// FindNotifications implements NotificationHandler
+func (h *notificationHandler) FindNotifications(w http.ResponseWriter, r *http.Request) {
+	principal, err := PrincipalFrom(r.Context())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	err = principal.Require(config.PermNotificationsRead)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	id := principal.UserId
+
+	response, err := h.Service.FindNotifications(id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
+
+// MarkNotificationRead implements NotificationHandler
+func (h *notificationHandler) MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	notificationId := chi.URLParam(r, "notification-id")
+	notificationIdInt, _ := strconv.Atoi(notificationId)
+
+	principal, err := PrincipalFrom(r.Context())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	err = principal.Require(config.PermNotificationsRead)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	id := principal.UserId
+
+	response, err := h.Service.MarkNotificationRead(notificationIdInt, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
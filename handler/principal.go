@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"learn/common"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserId      int
+	Role        string
+	Permissions []string
+	TokenId     string
+}
+
+// HasPermission reports whether the caller's token grants permission.
+func (p Principal) HasPermission(permission string) bool {
+	for _, granted := range p.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Require returns ErrForbidden unless the caller's token grants permission.
+func (p Principal) Require(permission string) error {
+	if !p.HasPermission(permission) {
+		return fmt.Errorf("user %d without %s : %w", p.UserId, permission, common.ErrForbidden)
+	}
+
+	return nil
+}
+
+// principalKey is the context key Auth stores the Principal under.
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying principal.
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the caller stored by Auth. It is ErrUnauthorized
+// when the request did not go through Auth.
+func PrincipalFrom(ctx context.Context) (Principal, error) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	if !ok || principal.UserId == 0 {
+		return Principal{}, fmt.Errorf("no principal in context : %w", common.ErrUnauthorized)
+	}
+
+	return principal, nil
+}
+
+// rolePrincipal returns the caller when the {role} URL parameter is their
+// role.
+func rolePrincipal(r *http.Request) (Principal, error) {
+	principal, err := PrincipalFrom(r.Context())
+	if err != nil {
+		return Principal{}, err
+	}
+
+	urlRole := chi.URLParam(r, "role")
+	if urlRole != principal.Role {
+		return Principal{}, fmt.Errorf("role %s on %s route : %w", principal.Role, urlRole, common.ErrForbidden)
+	}
+
+	return principal, nil
+}
+
+// permittedPrincipal returns the caller when rolePrincipal accepts them and
+// their token grants permission.
+func permittedPrincipal(r *http.Request, permission string) (Principal, error) {
+	principal, err := rolePrincipal(r)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	err = principal.Require(permission)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	return principal, nil
+}
+
+// adminPrincipal returns the caller when they are an admin on an admin
+// route.
+func adminPrincipal(r *http.Request) (Principal, error) {
+	principal, err := rolePrincipal(r)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	if principal.Role != "admin" {
+		return Principal{}, fmt.Errorf("role %s : %w", principal.Role, common.ErrForbidden)
+	}
+
+	return principal, nil
+}
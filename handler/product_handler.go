@@ -1,250 +1,529 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"learn/common"
+	"learn/config"
 	"learn/model"
 	"learn/service"
+	"mime/multipart"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
-	"strings"
-	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-playground/validator/v10"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 type ProductHandler interface {
 	// ADMIN
 	AddProduct(w http.ResponseWriter, r *http.Request)
 	FindProductById(w http.ResponseWriter, r *http.Request)
+	FindProductBySku(w http.ResponseWriter, r *http.Request)
+	FindProductByBarcode(w http.ResponseWriter, r *http.Request)
+	BarcodeLabel(w http.ResponseWriter, r *http.Request)
 	UpdateProduct(w http.ResponseWriter, r *http.Request)
 	DeleteProduct(w http.ResponseWriter, r *http.Request)
+	FindTrashedProducts(w http.ResponseWriter, r *http.Request)
+	RestoreProduct(w http.ResponseWriter, r *http.Request)
 
 	GetAllProductImagesByProductId(w http.ResponseWriter, r *http.Request)
 	UploadProductImage(w http.ResponseWriter, r *http.Request)
+	ImportProductImage(w http.ResponseWriter, r *http.Request)
+	UpdateProductImage(w http.ResponseWriter, r *http.Request)
+	ReorderProductImages(w http.ResponseWriter, r *http.Request)
 	DeleteProductImage(w http.ResponseWriter, r *http.Request)
 
 	// USER
 	FindAllProduct(w http.ResponseWriter, r *http.Request)
+	FindPublicProductById(w http.ResponseWriter, r *http.Request)
+	FindProductBySlug(w http.ResponseWriter, r *http.Request)
+	Sitemap(w http.ResponseWriter, r *http.Request)
 }
 
 type productHandler struct {
 	Service  service.ProductService
-	Validate *validator.Validate
+	Audit    service.AuditService
+	Validate *config.Validator
+	AppURL   string
 }
 
-func NewProductHandler(service service.ProductService, validate *validator.Validate) ProductHandler {
+func NewProductHandler(service service.ProductService, audit service.AuditService, validate *config.Validator, appURL string) ProductHandler {
 	return &productHandler{
 		Service:  service,
+		Audit:    audit,
 		Validate: validate,
+		AppURL:   appURL,
 	}
 }
 
 // AddProduct implements ProductHandler
 func (h *productHandler) AddProduct(w http.ResponseWriter, r *http.Request) {
-	urlRole := chi.URLParam(r, "role")
 	var req model.ProductReq
 
-	userInfo := r.Context().Value("userInfo").(jwt.MapClaims)
-	role := userInfo["role"].(string)
-
-	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(&req)
+	principal, err := permittedPrincipal(r, config.PermProductsWrite)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, err)
+		WriteError(w, err)
 		return
 	}
+	id := principal.UserId
 
-	if urlRole != role || urlRole != "admin" {
-		WriteErrorResponse(w, http.StatusUnauthorized, common.ErrUnauthorized)
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&req)
+	if err != nil {
+		WriteError(w, common.Invalid(err))
 		return
 	}
 
 	err = h.Validate.Struct(&req)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, err)
+		WriteError(w, h.Validate.Invalid(err, r.Header.Get("Accept-Language")))
 		return
 	}
 
-	responseProduct, err := h.Service.AddProduct(req)
+	responseProduct, err := h.Service.AddProduct(r.Context(), req, id)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		WriteError(w, err)
 		return
 	}
 
+	recordAudit(h.Audit, r, id, model.AuditCreate, model.AuditEntityProduct, responseProduct.Id, nil, responseProduct)
+
 	WriteDataResponse(w, http.StatusOK, responseProduct)
 }
 
 // FindProductById implements ProductHandler
 func (h *productHandler) FindProductById(w http.ResponseWriter, r *http.Request) {
-	urlRole := chi.URLParam(r, "role")
 	productId := chi.URLParam(r, "product-id")
 	productIdInt, _ := strconv.Atoi(productId)
 
-	userInfo := r.Context().Value("userInfo").(jwt.MapClaims)
-	role := userInfo["role"].(string)
+	_, err := rolePrincipal(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	currency := r.URL.Query().Get("currency")
+
+	responseProduct, err := h.Service.FindProductById(r.Context(), productIdInt, currency)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, responseProduct)
+}
+
+// FindProductBySku implements ProductHandler
+func (h *productHandler) FindProductBySku(w http.ResponseWriter, r *http.Request) {
+	sku := chi.URLParam(r, "sku")
+
+	_, err := adminPrincipal(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	responseProduct, err := h.Service.FindProductBySku(r.Context(), sku)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, responseProduct)
+}
+
+// FindProductByBarcode implements ProductHandler
+func (h *productHandler) FindProductByBarcode(w http.ResponseWriter, r *http.Request) {
+	barcode := chi.URLParam(r, "barcode")
 
-	if urlRole != role {
-		WriteErrorResponse(w, http.StatusUnauthorized, common.ErrUnauthorized)
+	_, err := adminPrincipal(r)
+	if err != nil {
+		WriteError(w, err)
 		return
 	}
 
-	responseProduct, err := h.Service.FindProductById(productIdInt)
+	responseProduct, err := h.Service.FindProductByBarcode(r.Context(), barcode)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		WriteError(w, err)
 		return
 	}
 
 	WriteDataResponse(w, http.StatusOK, responseProduct)
 }
 
+// BarcodeLabel implements ProductHandler
+func (h *productHandler) BarcodeLabel(w http.ResponseWriter, r *http.Request) {
+	productId := chi.URLParam(r, "product-id")
+	productIdInt, _ := strconv.Atoi(productId)
+
+	_, err := adminPrincipal(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	label, err := h.Service.BarcodeLabel(r.Context(), productIdInt)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Add("content-type", "image/png")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(label)
+}
+
 // UpdateProduct implements ProductHandler
 func (h *productHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	var req model.ProductReq
 
-	urlRole := chi.URLParam(r, "role")
 	productId := chi.URLParam(r, "product-id")
 	productIdInt, _ := strconv.Atoi(productId)
 
-	userInfo := r.Context().Value("userInfo").(jwt.MapClaims)
-	role := userInfo["role"].(string)
-
-	if urlRole != role {
-		WriteErrorResponse(w, http.StatusUnauthorized, common.ErrUnauthorized)
+	principal, err := permittedPrincipal(r, config.PermProductsWrite)
+	if err != nil {
+		WriteError(w, err)
 		return
 	}
+	id := principal.UserId
 
 	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(&req)
+	err = decoder.Decode(&req)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, err)
+		WriteError(w, common.Invalid(err))
 		return
 	}
 
 	err = h.Validate.Struct(&req)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, err)
+		WriteError(w, h.Validate.Invalid(err, r.Header.Get("Accept-Language")))
 		return
 	}
 
-	response, err := h.Service.UpdateProduct(req, productIdInt)
+	before, _ := h.Service.FindProductById(r.Context(), productIdInt, "")
+
+	response, err := h.Service.UpdateProduct(r.Context(), req, productIdInt, id)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		WriteError(w, err)
 		return
 	}
 
+	recordAudit(h.Audit, r, id, model.AuditUpdate, model.AuditEntityProduct, productIdInt, before, response)
+
 	WriteDataResponse(w, http.StatusOK, response)
 }
 
 // DeleteProduct implements ProductHandler
 func (h *productHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
-	urlRole := chi.URLParam(r, "role")
 	productId := chi.URLParam(r, "product-id")
 	productIdInt, _ := strconv.Atoi(productId)
 
-	userInfo := r.Context().Value("userInfo").(jwt.MapClaims)
-	role := userInfo["role"].(string)
+	principal, err := permittedPrincipal(r, config.PermProductsWrite)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	id := principal.UserId
+
+	before, _ := h.Service.FindProductById(r.Context(), productIdInt, "")
 
-	if urlRole != role {
-		WriteErrorResponse(w, http.StatusUnauthorized, common.ErrUnauthorized)
+	response, err := h.Service.DeleteProduct(r.Context(), productIdInt)
+	if err != nil {
+		WriteError(w, err)
 		return
 	}
 
-	response, err := h.Service.DeleteProduct(productIdInt)
+	recordAudit(h.Audit, r, id, model.AuditDelete, model.AuditEntityProduct, productIdInt, before, nil)
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
+
+// FindTrashedProducts implements ProductHandler
+func (h *productHandler) FindTrashedProducts(w http.ResponseWriter, r *http.Request) {
+
+	_, err := adminPrincipal(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	response, err := h.Service.FindTrashedProducts(r.Context())
 	if err != nil {
-		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		WriteError(w, err)
 		return
 	}
 
 	WriteDataResponse(w, http.StatusOK, response)
 }
 
+// RestoreProduct implements ProductHandler
+func (h *productHandler) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	productId := chi.URLParam(r, "product-id")
+	productIdInt, _ := strconv.Atoi(productId)
+
+	principal, err := permittedPrincipal(r, config.PermProductsWrite)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	id := principal.UserId
+
+	response, err := h.Service.RestoreProduct(r.Context(), productIdInt)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	after, _ := h.Service.FindProductById(r.Context(), productIdInt, "")
+	recordAudit(h.Audit, r, id, model.AuditRestore, model.AuditEntityProduct, productIdInt, nil, after)
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
+
 // GetAllProductImagesByProductId implements ProductHandler
 func (h *productHandler) GetAllProductImagesByProductId(w http.ResponseWriter, r *http.Request) {
-	urlRole := chi.URLParam(r, "role")
 	productId := chi.URLParam(r, "product-id")
 	productIdInt, _ := strconv.Atoi(productId)
 
-	userInfo := r.Context().Value("userInfo").(jwt.MapClaims)
-	role := userInfo["role"].(string)
-
-	if urlRole != role {
-		WriteErrorResponse(w, http.StatusUnauthorized, common.ErrUnauthorized)
+	_, err := rolePrincipal(r)
+	if err != nil {
+		WriteError(w, err)
 		return
 	}
 
-	response, err := h.Service.FindAllProductImagesByProductId(productIdInt)
+	response, err := h.Service.FindAllProductImagesByProductId(r.Context(), productIdInt)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		WriteError(w, err)
 		return
 	}
 
 	WriteDataResponse(w, http.StatusOK, response)
 }
 
-// UploadProductImage implements ProductHandler
+// UploadProductImage implements ProductHandler. Several file-image parts
+// can be sent at once; is_primary applies to the first file and alt_text
+// may be repeated once per file. Each file gets its own result.
 func (h *productHandler) UploadProductImage(w http.ResponseWriter, r *http.Request) {
 	var req model.ProductImagesUploadReq
 
-	urlRole := chi.URLParam(r, "role")
 	productId := chi.URLParam(r, "product-id")
 	productIdInt, _ := strconv.Atoi(productId)
 
-	userInfo := r.Context().Value("userInfo").(jwt.MapClaims)
-	role := userInfo["role"].(string)
+	principal, err := permittedPrincipal(r, config.PermProductsWrite)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	id := principal.UserId
 
-	if urlRole != role {
-		WriteErrorResponse(w, http.StatusUnauthorized, common.ErrUnauthorized)
+	r.Body = http.MaxBytesReader(w, r.Body, maxImageUploadSize)
+
+	err = r.ParseMultipartForm(32 << 20)
+	if err != nil {
+		WriteError(w, clientError(err))
 		return
 	}
 
-	isPrimary := r.FormValue("is_primary")
+	files := r.MultipartForm.File["file-image"]
+	if len(files) == 0 {
+		WriteError(w, common.Invalid(http.ErrMissingFile))
+		return
+	}
+
+	altTexts := r.MultipartForm.Value["alt_text"]
+	response := model.ProductImagesUploadRes{Results: []model.ProductImageUploadResult{}}
+	uploaded := 0
+
+	for i, header := range files {
+		req.IsPrimary = r.FormValue("is_primary")
+		if i > 0 {
+			req.IsPrimary = "no"
+		}
+
+		req.AltText = ""
+		if i < len(altTexts) {
+			req.AltText = altTexts[i]
+		}
+
+		image, err := h.uploadImage(r, req, productIdInt, header)
+
+		result := model.ProductImageUploadResult{FileName: header.Filename, Uploaded: err == nil}
+		if err != nil {
+			result.Error = uploadError(r.Context(), err)
+		} else {
+			result.Image = &image
+			uploaded++
+			recordAudit(h.Audit, r, id, model.AuditCreate, model.AuditEntityProductImage, image.Id, nil, image)
+		}
+
+		response.Results = append(response.Results, result)
+	}
+
+	response.Message = fmt.Sprintf("%d of %d product images uploaded", uploaded, len(files))
+
+	if uploaded == 0 {
+		WriteDataResponse(w, http.StatusBadRequest, response)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
+
+func (h *productHandler) uploadImage(r *http.Request, req model.ProductImagesUploadReq, productId int, header *multipart.FileHeader) (model.ProductImageRes, error) {
+	err := h.Validate.Struct(&req)
+	if err != nil {
+		return model.ProductImageRes{}, h.Validate.Invalid(err, r.Header.Get("Accept-Language"))
+	}
+
+	uploadedFile, err := header.Open()
+	if err != nil {
+		return model.ProductImageRes{}, err
+	}
+	defer uploadedFile.Close()
 
-	uploadedFile, header, err := r.FormFile("file-image")
+	data, ext, err := readImage(uploadedFile)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		return model.ProductImageRes{}, err
+	}
+
+	return h.Service.UploadProductImages(r.Context(), req, productId, ext, data)
+}
+
+// ImportProductImage implements ProductHandler
+func (h *productHandler) ImportProductImage(w http.ResponseWriter, r *http.Request) {
+	var req model.ProductImageImportReq
+
+	productId := chi.URLParam(r, "product-id")
+	productIdInt, _ := strconv.Atoi(productId)
+
+	principal, err := permittedPrincipal(r, config.PermProductsWrite)
+	if err != nil {
+		WriteError(w, err)
 		return
 	}
+	id := principal.UserId
 
-	fileExt := filepath.Ext(header.Filename)
-	originalFileName := strings.TrimSuffix(filepath.Base(header.Filename), filepath.Ext(header.Filename))
-	now := time.Now()
-	filename := strings.ReplaceAll(strings.ToLower(originalFileName), " ", "-") + "-" + fmt.Sprintf("%v", now.Unix()) + fileExt
-	filePath := "pringgodigdo.com/" + filename
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&req)
+	if err != nil {
+		WriteError(w, common.Invalid(err))
+		return
+	}
 
-	req.IsPrimary = isPrimary
+	err = h.Validate.Struct(&req)
+	if err != nil {
+		WriteError(w, h.Validate.Invalid(err, r.Header.Get("Accept-Language")))
+		return
+	}
 
-	response, err := h.Service.UploadProductImages(req, productIdInt, filePath)
+	data, ext, err := fetchRemoteImage(r.Context(), req.Url)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		WriteError(w, clientError(err))
 		return
 	}
 
-	out, err := os.Create("files/" + filename)
+	uploadReq := model.ProductImagesUploadReq{
+		IsPrimary: req.IsPrimary,
+		AltText:   req.AltText,
+	}
+
+	response, err := h.Service.UploadProductImages(r.Context(), uploadReq, productIdInt, ext, data)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		WriteError(w, err)
 		return
 	}
-	defer out.Close()
-	_, err = io.Copy(out, uploadedFile)
+
+	recordAudit(h.Audit, r, id, model.AuditCreate, model.AuditEntityProductImage, response.Id, nil, response)
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
+
+// UpdateProductImage implements ProductHandler
+func (h *productHandler) UpdateProductImage(w http.ResponseWriter, r *http.Request) {
+	var req model.ProductImageReq
+
+	productId := chi.URLParam(r, "product-id")
+	productIdInt, _ := strconv.Atoi(productId)
+
+	productImageId := chi.URLParam(r, "product-image-id")
+	productImageIdInt, _ := strconv.Atoi(productImageId)
+
+	principal, err := permittedPrincipal(r, config.PermProductsWrite)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		WriteError(w, err)
 		return
 	}
+	id := principal.UserId
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&req)
+	if err != nil {
+		WriteError(w, common.Invalid(err))
+		return
+	}
+
+	err = h.Validate.Struct(&req)
+	if err != nil {
+		WriteError(w, h.Validate.Invalid(err, r.Header.Get("Accept-Language")))
+		return
+	}
+
+	before := h.findProductImage(r.Context(), productIdInt, productImageIdInt)
+
+	response, err := h.Service.UpdateProductImage(r.Context(), req, productImageIdInt, productIdInt)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	recordAudit(h.Audit, r, id, model.AuditUpdate, model.AuditEntityProductImage, productImageIdInt, before, response)
 
 	WriteDataResponse(w, http.StatusOK, response)
+}
+
+// ReorderProductImages implements ProductHandler
+func (h *productHandler) ReorderProductImages(w http.ResponseWriter, r *http.Request) {
+	var req model.ProductImageOrderReq
+
+	productId := chi.URLParam(r, "product-id")
+	productIdInt, _ := strconv.Atoi(productId)
+
+	principal, err := permittedPrincipal(r, config.PermProductsWrite)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	id := principal.UserId
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&req)
+	if err != nil {
+		WriteError(w, common.Invalid(err))
+		return
+	}
+
+	err = h.Validate.Struct(&req)
+	if err != nil {
+		WriteError(w, h.Validate.Invalid(err, r.Header.Get("Accept-Language")))
+		return
+	}
+
+	before, _ := h.Service.FindAllProductImagesByProductId(r.Context(), productIdInt)
 
+	response, err := h.Service.ReorderProductImages(r.Context(), req, productIdInt)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	recordAudit(h.Audit, r, id, model.AuditReorder, model.AuditEntityProduct, productIdInt, before, response)
+
+	WriteDataResponse(w, http.StatusOK, response)
 }
 
 // DeleteProductImage implements ProductHandler
 func (h *productHandler) DeleteProductImage(w http.ResponseWriter, r *http.Request) {
-	urlRole := chi.URLParam(r, "role")
 
 	productId := chi.URLParam(r, "product-id")
 	productIdInt, _ := strconv.Atoi(productId)
@@ -252,31 +531,103 @@ func (h *productHandler) DeleteProductImage(w http.ResponseWriter, r *http.Reque
 	productImageId := chi.URLParam(r, "product-image-id")
 	productImageIdInt, _ := strconv.Atoi(productImageId)
 
-	userInfo := r.Context().Value("userInfo").(jwt.MapClaims)
-	role := userInfo["role"].(string)
-
-	if urlRole != role {
-		WriteErrorResponse(w, http.StatusUnauthorized, common.ErrUnauthorized)
+	principal, err := permittedPrincipal(r, config.PermProductsWrite)
+	if err != nil {
+		WriteError(w, err)
 		return
 	}
+	id := principal.UserId
+
+	before := h.findProductImage(r.Context(), productIdInt, productImageIdInt)
 
-	response, err := h.Service.DeleteProductImageId(productImageIdInt, productIdInt)
+	response, err := h.Service.DeleteProductImageId(r.Context(), productImageIdInt, productIdInt)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		WriteError(w, err)
 		return
 	}
 
+	recordAudit(h.Audit, r, id, model.AuditDelete, model.AuditEntityProductImage, productImageIdInt, before, nil)
+
 	WriteDataResponse(w, http.StatusOK, response)
 }
 
+// findProductImage returns the image for the audit log, or nil when it is
+// not in the product's gallery.
+func (h *productHandler) findProductImage(ctx context.Context, productId int, prodImgId int) *model.ProductImageRes {
+	images, err := h.Service.FindAllProductImagesByProductId(ctx, productId)
+	if err != nil {
+		return nil
+	}
+
+	for _, image := range images.ProductImages {
+		if image.Id == prodImgId {
+			return &image
+		}
+	}
+
+	return nil
+}
+
 // USER
 // FindAllProduct implements ProductHandler
 func (h *productHandler) FindAllProduct(w http.ResponseWriter, r *http.Request) {
-	response, err := h.Service.FindAllProduct()
+	currency := r.URL.Query().Get("currency")
+
+	response, err := h.Service.FindAllProduct(r.Context(), currency)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		WriteError(w, err)
 		return
 	}
 
 	WriteDataResponse(w, http.StatusOK, response)
 }
+
+// FindPublicProductById implements ProductHandler
+func (h *productHandler) FindPublicProductById(w http.ResponseWriter, r *http.Request) {
+	productId := chi.URLParam(r, "product-id")
+	productIdInt, _ := strconv.Atoi(productId)
+	currency := r.URL.Query().Get("currency")
+
+	response, err := h.Service.FindPublicProductById(r.Context(), productIdInt, currency)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
+
+// FindProductBySlug implements ProductHandler
+func (h *productHandler) FindProductBySlug(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	currency := r.URL.Query().Get("currency")
+
+	response, err := h.Service.FindProductBySlug(r.Context(), slug, currency)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	if response.Slug != slug {
+		target := "/products/" + response.Slug
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
+
+// Sitemap implements ProductHandler
+func (h *productHandler) Sitemap(w http.ResponseWriter, r *http.Request) {
+	response, err := h.Service.Sitemap(r.Context(), h.AppURL)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteXMLResponse(w, http.StatusOK, response)
+}
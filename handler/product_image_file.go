@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"learn/common"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Limits for product image uploads and imports.
+const (
+	maxImageSize        = 10 << 20
+	maxImageUploadSize  = 50 << 20
+	remoteImageTimeout  = 15 * time.Second
+	remoteImageRedirect = 3
+)
+
+// imageExtensions maps the accepted image types, as sniffed from the
+// content, to the extension the file is stored with.
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// readImage reads at most maxImageSize bytes of an image and returns them
+// with the extension for its sniffed content type.
+func readImage(src io.Reader) ([]byte, string, error) {
+	data, err := io.ReadAll(io.LimitReader(src, maxImageSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(data) > maxImageSize {
+		return nil, "", fmt.Errorf("image over %d MB : %w", maxImageSize>>20, common.ErrFileTooLarge)
+	}
+
+	ext, ok := imageExtensions[http.DetectContentType(data)]
+	if !ok {
+		return nil, "", fmt.Errorf("image : %w", common.ErrUnsupportedFile)
+	}
+
+	return data, ext, nil
+}
+
+// remoteImageClient only connects to public addresses. The check runs on
+// the resolved address of every connection, redirects included, so a host
+// name cannot be pointed at an internal service.
+var remoteImageClient = &http.Client{
+	Timeout: remoteImageTimeout,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: publicAddressOnly,
+		}).DialContext,
+		TLSHandshakeTimeout:    5 * time.Second,
+		ResponseHeaderTimeout:  10 * time.Second,
+		MaxResponseHeaderBytes: 1 << 20,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= remoteImageRedirect {
+			return fmt.Errorf("too many redirects : %w", common.ErrUnsafeURL)
+		}
+
+		return checkImageURL(req.URL)
+	},
+}
+
+// fetchRemoteImage downloads an image over http or https and returns it
+// with the extension for its type.
+func fetchRemoteImage(ctx context.Context, rawURL string) ([]byte, string, error) {
+	imageURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = checkImageURL(imageURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL.String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := remoteImageClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("image url responded %s : %w", resp.Status, common.ErrUploadFile)
+	}
+
+	if resp.ContentLength > maxImageSize {
+		return nil, "", fmt.Errorf("image over %d MB : %w", maxImageSize>>20, common.ErrFileTooLarge)
+	}
+
+	return readImage(resp.Body)
+}
+
+// checkImageURL accepts http and https URLs with a host and without
+// credentials. Whether the host is public is only known once it is
+// resolved, so publicAddressOnly checks that when connecting.
+func checkImageURL(imageURL *url.URL) error {
+	if imageURL.Scheme != "http" && imageURL.Scheme != "https" {
+		return fmt.Errorf("scheme %q : %w", imageURL.Scheme, common.ErrUnsafeURL)
+	}
+
+	if imageURL.Hostname() == "" {
+		return fmt.Errorf("url without host : %w", common.ErrUnsafeURL)
+	}
+
+	if imageURL.User != nil {
+		return fmt.Errorf("credentials in url : %w", common.ErrUnsafeURL)
+	}
+
+	return nil
+}
+
+// reservedNetworks are the special-purpose ranges net.IP has no method
+// for: "this network", carrier-grade NAT, IETF protocol assignments,
+// documentation, benchmarking, the reserved block with broadcast, and the
+// IPv6 translation, discard and documentation prefixes.
+var reservedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"240.0.0.0/4",
+	"64:ff9b::/96",
+	"64:ff9b:1::/48",
+	"100::/64",
+	"2001:db8::/32",
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+
+	return networks
+}
+
+// publicAddressOnly is a net.Dialer Control function that refuses every
+// address that is not a public unicast address.
+func publicAddressOnly(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("address %s : %w", host, common.ErrUnsafeURL)
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("address %s : %w", host, common.ErrUnsafeURL)
+	}
+
+	for _, reserved := range reservedNetworks {
+		if reserved.Contains(ip) {
+			return fmt.Errorf("address %s : %w", host, common.ErrUnsafeURL)
+		}
+	}
+
+	return nil
+}
+
+// clientError classifies an error caused by what the client sent, such as a
+// malformed upload or an image URL that cannot be fetched. Errors that
+// already carry a kind keep it.
+func clientError(err error) error {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return fmt.Errorf("%v : %w", err, common.ErrFileTooLarge)
+	}
+
+	if common.KindOf(err) == common.KindInternal {
+		return common.Invalid(err)
+	}
+
+	return err
+}
+
+// uploadError is the per-file message shown for a failed upload: the field
+// messages of a validation failure, else the domain message. Internal errors
+// are logged and shown with the generic message.
+func uploadError(ctx context.Context, err error) string {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return common.ErrFileTooLarge.Message
+	}
+
+	domainErr := common.AsError(err)
+	if domainErr.Kind == common.KindInternal {
+		slog.ErrorContext(ctx, "image upload failed", "error", err)
+	}
+
+	if len(domainErr.Fields) > 0 {
+		messages := make([]string, 0, len(domainErr.Fields))
+		for _, field := range domainErr.Fields {
+			messages = append(messages, field.Message)
+		}
+
+		return strings.Join(messages, "; ")
+	}
+
+	return domainErr.Message
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"learn/common"
+	"learn/config"
+	"learn/model"
+	"learn/service"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxImportFileSize limits product import uploads to 20 MB.
+const maxImportFileSize = 20 << 20
+
+type ProductImportHandler interface {
+	// ADMIN
+	ExportProducts(w http.ResponseWriter, r *http.Request)
+	ImportProducts(w http.ResponseWriter, r *http.Request)
+	FindImportJob(w http.ResponseWriter, r *http.Request)
+}
+
+type productImportHandler struct {
+	Service service.ProductImportService
+	Audit   service.AuditService
+}
+
+func NewProductImportHandler(service service.ProductImportService, audit service.AuditService) ProductImportHandler {
+	return &productImportHandler{
+		Service: service,
+		Audit:   audit,
+	}
+}
+
+var productFileContentTypes = map[string]string{
+	service.FormatCSV:  "text/csv",
+	service.FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ExportProducts implements ProductImportHandler
+func (h *productImportHandler) ExportProducts(w http.ResponseWriter, r *http.Request) {
+
+	_, err := adminPrincipal(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = service.FormatCSV
+	}
+
+	contentType, ok := productFileContentTypes[format]
+	if !ok {
+		WriteError(w, fmt.Errorf("format %q : %w", format, common.ErrNotMatch))
+		return
+	}
+
+	var file bytes.Buffer
+	err = h.Service.ExportProducts(r.Context(), format, &file)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	w.Header().Add("content-type", contentType)
+	w.Header().Add("content-disposition", fmt.Sprintf("attachment; filename=\"products.%s\"", format))
+	w.WriteHeader(http.StatusOK)
+	_, _ = file.WriteTo(w)
+}
+
+// ImportProducts implements ProductImportHandler. The format comes from the
+// format query parameter or else the file extension; dry_run=true only
+// validates the file.
+func (h *productImportHandler) ImportProducts(w http.ResponseWriter, r *http.Request) {
+
+	principal, err := permittedPrincipal(r, config.PermProductsWrite)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	id := principal.UserId
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportFileSize)
+
+	uploadedFile, header, err := r.FormFile("file-products")
+	if err != nil {
+		WriteError(w, clientError(err))
+		return
+	}
+	defer uploadedFile.Close()
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+	}
+
+	if _, ok := productFileContentTypes[format]; !ok {
+		WriteError(w, fmt.Errorf("format %q : %w", format, common.ErrNotMatch))
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	response, err := h.Service.StartImport(uploadedFile, header.Filename, format, dryRun, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	recordAudit(h.Audit, r, id, model.AuditImport, model.AuditEntityProductImport, response.Id, nil, response)
+
+	WriteDataResponse(w, http.StatusAccepted, response)
+}
+
+// FindImportJob implements ProductImportHandler
+func (h *productImportHandler) FindImportJob(w http.ResponseWriter, r *http.Request) {
+	jobId := chi.URLParam(r, "job-id")
+	jobIdInt, _ := strconv.Atoi(jobId)
+
+	_, err := adminPrincipal(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	response, err := h.Service.FindImportJob(jobIdInt)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"learn/config"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handlers are the handlers NewRouter serves.
+type Handlers struct {
+	User          UserHandler
+	Address       AddressHandler
+	Product       ProductHandler
+	ProductImport ProductImportHandler
+	Inventory     InventoryHandler
+	Warehouse     WarehouseHandler
+	Tax           TaxHandler
+	ExchangeRate  ExchangeRateHandler
+	Audit         AuditHandler
+	Notification  NotificationHandler
+	Health        HealthHandler
+}
+
+// NewRouter returns the API routes served by h. Authenticated routes need a
+// token tokens accepts, and requests that run longer than requestTimeout are
+// canceled.
+func NewRouter(h Handlers, tokens *config.Tokens, requestTimeout time.Duration) http.Handler {
+	auth := Auth(tokens)
+
+	router := chi.NewRouter()
+	router.Use(middleware.RealIP)
+	router.Use(RequestID)
+	router.Use(Logger)
+	router.Use(Timeout(requestTimeout))
+
+	// HEALTH
+	router.Get("/healthz", h.Health.Live)
+	router.Get("/readyz", h.Health.Ready)
+
+	// USER
+	// Public
+	router.Post("/register", h.User.Register)
+	router.Post("/login", h.User.Login)
+	// Auth
+	router.Get("/profile", auth(h.User.Profile))
+	router.Post("/change-password", auth(h.User.ChangePassword))
+
+	// ADMIN
+	router.Post("/register-admin", h.User.RegisterAdmin)
+
+	// ADDRESS
+	router.Post("/{user-id}/addresses", auth(h.Address.AddAddress))
+	router.Get("/{user-id}/addresses", auth(h.Address.GetAddresses))
+	router.Put("/{user-id}/addresses/{address-id}", auth(h.Address.UpdateAddress))
+	router.Delete("/{user-id}/addresses/{address-id}", auth(h.Address.DeleteAddress))
+
+	// PRODUCT
+	// ADMIN
+	router.Post("/{role}/products", auth(h.Product.AddProduct))
+	router.Get("/{role}/products/{product-id}", auth(h.Product.FindProductById))
+	router.Post("/{role}/products/{product-id}", auth(h.Product.UpdateProduct))
+	router.Delete("/{role}/products/{product-id}", auth(h.Product.DeleteProduct))
+	router.Get("/{role}/products/trash", auth(h.Product.FindTrashedProducts))
+	router.Post("/{role}/products/{product-id}/restore", auth(h.Product.RestoreProduct))
+	router.Get("/{role}/products/sku/{sku}", auth(h.Product.FindProductBySku))
+	router.Get("/{role}/products/barcode/{barcode}", auth(h.Product.FindProductByBarcode))
+	router.Get("/{role}/products/{product-id}/barcode-label", auth(h.Product.BarcodeLabel))
+	router.Get("/{role}/products/export", auth(h.ProductImport.ExportProducts))
+	router.Post("/{role}/products/import", auth(h.ProductImport.ImportProducts))
+	router.Get("/{role}/products/import/{job-id}", auth(h.ProductImport.FindImportJob))
+
+	// PRODUCT IMAGES
+	router.Get("/{role}/products/{product-id}/images", auth(h.Product.GetAllProductImagesByProductId))
+	router.Post("/{role}/products/{product-id}/images", auth(h.Product.UploadProductImage))
+	router.Post("/{role}/products/{product-id}/images/import", auth(h.Product.ImportProductImage))
+	router.Post("/{role}/products/{product-id}/images/order", auth(h.Product.ReorderProductImages))
+	router.Post("/{role}/products/{product-id}/images/{product-image-id}", auth(h.Product.UpdateProductImage))
+	router.Delete("/{role}/products/{product-id}/images/{product-image-id}", auth(h.Product.DeleteProductImage))
+
+	// INVENTORY
+	router.Get("/{role}/products/{product-id}/inventory-movements", auth(h.Inventory.FindMovementsByProductId))
+	router.Post("/{role}/products/{product-id}/inventory-movements", auth(h.Inventory.RecordMovement))
+	router.Get("/{role}/inventory/reconciliation", auth(h.Inventory.Reconcile))
+
+	// WAREHOUSE
+	router.Post("/{role}/warehouses", auth(h.Warehouse.AddWarehouse))
+	router.Get("/{role}/warehouses", auth(h.Warehouse.FindAllWarehouse))
+	router.Put("/{role}/warehouses/{warehouse-id}", auth(h.Warehouse.UpdateWarehouse))
+	router.Get("/{role}/warehouses/{warehouse-id}/stocks", auth(h.Warehouse.FindStocksByWarehouseId))
+	router.Post("/{role}/stock-transfers", auth(h.Warehouse.TransferStock))
+	router.Post("/{role}/stock-allocations", auth(h.Warehouse.AllocateStock))
+
+	// TAX
+	router.Post("/{role}/tax-classes", auth(h.Tax.AddTaxClass))
+	router.Get("/{role}/tax-classes", auth(h.Tax.FindAllTaxClass))
+	router.Put("/{role}/tax-classes/{tax-class-id}", auth(h.Tax.UpdateTaxClass))
+
+	// EXCHANGE RATE
+	router.Post("/{role}/exchange-rates", auth(h.ExchangeRate.SetExchangeRate))
+	router.Get("/{role}/exchange-rates", auth(h.ExchangeRate.FindAllExchangeRate))
+	router.Post("/{role}/exchange-rates/import", auth(h.ExchangeRate.ImportExchangeRates))
+
+	// AUDIT LOG
+	router.Get("/{role}/audit-logs", auth(h.Audit.FindAuditLogs))
+
+	// NOTIFICATION
+	router.Post("/products/{product-id}/stock-subscriptions", auth(h.Notification.SubscribeBackInStock))
+	router.Get("/notifications", auth(h.Notification.FindNotifications))
+	router.Post("/notifications/{notification-id}/read", auth(h.Notification.MarkNotificationRead))
+
+	// USER
+	router.Get("/products", h.Product.FindAllProduct)
+	router.Get("/products/{product-id:[0-9]+}", h.Product.FindPublicProductById)
+	router.Get("/products/{slug}", h.Product.FindProductBySlug)
+	router.Get("/sitemap.xml", h.Product.Sitemap)
+
+	return router
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"encoding/json"
+	"learn/common"
+	"learn/config"
+	"learn/model"
+	"learn/service"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type TaxHandler interface {
+	// ADMIN
+	AddTaxClass(w http.ResponseWriter, r *http.Request)
+	FindAllTaxClass(w http.ResponseWriter, r *http.Request)
+	UpdateTaxClass(w http.ResponseWriter, r *http.Request)
+}
+
+type taxHandler struct {
+	Service  service.TaxService
+	Validate *config.Validator
+}
+
+func NewTaxHandler(service service.TaxService, validate *config.Validator) TaxHandler {
+	return &taxHandler{
+		Service:  service,
+		Validate: validate,
+	}
+}
+
+// AddTaxClass implements TaxHandler
+func (h *taxHandler) AddTaxClass(w http.ResponseWriter, r *http.Request) {
+	var req model.TaxClassReq
+
+	_, err := permittedPrincipal(r, config.PermTaxWrite)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&req)
+	if err != nil {
+		WriteError(w, common.Invalid(err))
+		return
+	}
+
+	err = h.Validate.Struct(&req)
+	if err != nil {
+		WriteError(w, h.Validate.Invalid(err, r.Header.Get("Accept-Language")))
+		return
+	}
+
+	response, err := h.Service.AddTaxClass(req)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
+
+// FindAllTaxClass implements TaxHandler
+func (h *taxHandler) FindAllTaxClass(w http.ResponseWriter, r *http.Request) {
+
+	_, err := adminPrincipal(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	response, err := h.Service.FindAllTaxClass()
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
+
+// UpdateTaxClass implements TaxHandler
+func (h *taxHandler) UpdateTaxClass(w http.ResponseWriter, r *http.Request) {
+	var req model.TaxClassReq
+
+	taxClassId := chi.URLParam(r, "tax-class-id")
+	taxClassIdInt, _ := strconv.Atoi(taxClassId)
+
+	_, err := permittedPrincipal(r, config.PermTaxWrite)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&req)
+	if err != nil {
+		WriteError(w, common.Invalid(err))
+		return
+	}
+
+	err = h.Validate.Struct(&req)
+	if err != nil {
+		WriteError(w, h.Validate.Invalid(err, r.Header.Get("Accept-Language")))
+		return
+	}
+
+	response, err := h.Service.UpdateTaxClass(req, taxClassIdInt)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
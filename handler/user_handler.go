@@ -2,12 +2,11 @@ package handler
 
 import (
 	"encoding/json"
+	"learn/common"
+	"learn/config"
 	"learn/model"
 	"learn/service"
 	"net/http"
-
-	"github.com/go-playground/validator/v10"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 type UserHandler interface {
@@ -23,12 +22,14 @@ type UserHandler interface {
 
 type userHandler struct {
 	Service  service.UserServive
-	Validate *validator.Validate
+	Audit    service.AuditService
+	Validate *config.Validator
 }
 
-func NewUserHandler(srv service.UserServive, val *validator.Validate) UserHandler {
+func NewUserHandler(srv service.UserServive, audit service.AuditService, val *config.Validator) UserHandler {
 	return &userHandler{
 		Service:  srv,
+		Audit:    audit,
 		Validate: val,
 	}
 }
@@ -40,22 +41,24 @@ func (h *userHandler) Register(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&req)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, err)
+		WriteError(w, common.Invalid(err))
 		return
 	}
 
 	err = h.Validate.Struct(&req)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, err)
+		WriteError(w, h.Validate.Invalid(err, r.Header.Get("Accept-Language")))
 		return
 	}
 
-	userRgis, err := h.Service.Register(req)
+	userRgis, err := h.Service.Register(r.Context(), req)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		WriteError(w, err)
 		return
 	}
 
+	recordAudit(h.Audit, r, userRgis.Id, model.AuditRegister, model.AuditEntityUser, userRgis.Id, nil, userRgis)
+
 	WriteDataResponse(w, http.StatusOK, userRgis)
 }
 
@@ -66,19 +69,19 @@ func (h *userHandler) Login(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&req)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, err)
+		WriteError(w, common.Invalid(err))
 		return
 	}
 
 	err = h.Validate.Struct(req)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, err)
+		WriteError(w, h.Validate.Invalid(err, r.Header.Get("Accept-Language")))
 		return
 	}
 
-	token, err := h.Service.Login(req)
+	token, err := h.Service.Login(r.Context(), req)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		WriteError(w, err)
 		return
 	}
 
@@ -87,13 +90,16 @@ func (h *userHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 // Profile implements UserHandler
 func (h *userHandler) Profile(w http.ResponseWriter, r *http.Request) {
-	userInfo := r.Context().Value("userInfo").(jwt.MapClaims)
-	user := userInfo["user_id"].(float64)
-	id := int(user)
+	principal, err := PrincipalFrom(r.Context())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	id := principal.UserId
 
-	userProfile, err := h.Service.Profile(id)
+	userProfile, err := h.Service.Profile(r.Context(), id)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		WriteError(w, err)
 		return
 	}
 
@@ -107,26 +113,31 @@ func (h *userHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&req)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, err)
+		WriteError(w, common.Invalid(err))
 		return
 	}
 
 	err = h.Validate.Struct(&req)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, err)
+		WriteError(w, h.Validate.Invalid(err, r.Header.Get("Accept-Language")))
 		return
 	}
 
-	userInfo := r.Context().Value("userInfo").(jwt.MapClaims)
-	user := userInfo["user_id"].(float64)
-	id := int(user)
+	principal, err := PrincipalFrom(r.Context())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	id := principal.UserId
 
-	passChange, err := h.Service.ChangePassword(id, req)
+	passChange, err := h.Service.ChangePassword(r.Context(), id, req)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, err)
+		WriteError(w, err)
 		return
 	}
 
+	recordAudit(h.Audit, r, id, model.AuditChangePassword, model.AuditEntityUser, id, nil, nil)
+
 	WriteDataResponse(w, http.StatusOK, passChange)
 }
 
@@ -137,21 +148,24 @@ func (h *userHandler) RegisterAdmin(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
 	err := decoder.Decode(&req)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, err)
+		WriteError(w, common.Invalid(err))
 		return
 	}
 
 	err = h.Validate.Struct(&req)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusBadRequest, err)
+		WriteError(w, h.Validate.Invalid(err, r.Header.Get("Accept-Language")))
 		return
 	}
 
-	newUser, err := h.Service.RegisterAdmin(req)
+	newUser, err := h.Service.RegisterAdmin(r.Context(), req)
 	if err != nil {
-		WriteErrorResponse(w, http.StatusInternalServerError, err)
+		WriteError(w, err)
 		return
 	}
 
+	// Admins register themselves with the admin code; nobody is signed in.
+	recordAudit(h.Audit, r, newUser.Id, model.AuditRegister, model.AuditEntityUser, newUser.Id, nil, newUser)
+
 	WriteDataResponse(w, http.StatusOK, newUser)
 }
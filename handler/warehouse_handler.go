@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"encoding/json"
+	"learn/common"
+	"learn/config"
+	"learn/model"
+	"learn/service"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type WarehouseHandler interface {
+	// ADMIN
+	AddWarehouse(w http.ResponseWriter, r *http.Request)
+	FindAllWarehouse(w http.ResponseWriter, r *http.Request)
+	UpdateWarehouse(w http.ResponseWriter, r *http.Request)
+	FindStocksByWarehouseId(w http.ResponseWriter, r *http.Request)
+	TransferStock(w http.ResponseWriter, r *http.Request)
+	AllocateStock(w http.ResponseWriter, r *http.Request)
+}
+
+type warehouseHandler struct {
+	Service  service.WarehouseService
+	Audit    service.AuditService
+	Validate *config.Validator
+}
+
+func NewWarehouseHandler(service service.WarehouseService, audit service.AuditService, validate *config.Validator) WarehouseHandler {
+	return &warehouseHandler{
+		Service:  service,
+		Audit:    audit,
+		Validate: validate,
+	}
+}
+
+// AddWarehouse implements WarehouseHandler
+func (h *warehouseHandler) AddWarehouse(w http.ResponseWriter, r *http.Request) {
+	var req model.WarehouseReq
+
+	_, err := permittedPrincipal(r, config.PermWarehousesWrite)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&req)
+	if err != nil {
+		WriteError(w, common.Invalid(err))
+		return
+	}
+
+	err = h.Validate.Struct(&req)
+	if err != nil {
+		WriteError(w, h.Validate.Invalid(err, r.Header.Get("Accept-Language")))
+		return
+	}
+
+	response, err := h.Service.AddWarehouse(r.Context(), req)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
+
+// FindAllWarehouse implements WarehouseHandler
+func (h *warehouseHandler) FindAllWarehouse(w http.ResponseWriter, r *http.Request) {
+
+	_, err := adminPrincipal(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	response, err := h.Service.FindAllWarehouse(r.Context())
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
+
+// UpdateWarehouse implements WarehouseHandler
+func (h *warehouseHandler) UpdateWarehouse(w http.ResponseWriter, r *http.Request) {
+	var req model.WarehouseReq
+
+	warehouseId := chi.URLParam(r, "warehouse-id")
+	warehouseIdInt, _ := strconv.Atoi(warehouseId)
+
+	_, err := permittedPrincipal(r, config.PermWarehousesWrite)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&req)
+	if err != nil {
+		WriteError(w, common.Invalid(err))
+		return
+	}
+
+	err = h.Validate.Struct(&req)
+	if err != nil {
+		WriteError(w, h.Validate.Invalid(err, r.Header.Get("Accept-Language")))
+		return
+	}
+
+	response, err := h.Service.UpdateWarehouse(r.Context(), req, warehouseIdInt)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
+
+// FindStocksByWarehouseId implements WarehouseHandler
+func (h *warehouseHandler) FindStocksByWarehouseId(w http.ResponseWriter, r *http.Request) {
+	warehouseId := chi.URLParam(r, "warehouse-id")
+	warehouseIdInt, _ := strconv.Atoi(warehouseId)
+
+	_, err := adminPrincipal(r)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	response, err := h.Service.FindStocksByWarehouseId(r.Context(), warehouseIdInt)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
+
+// TransferStock implements WarehouseHandler
+func (h *warehouseHandler) TransferStock(w http.ResponseWriter, r *http.Request) {
+	var req model.StockTransferReq
+
+	principal, err := permittedPrincipal(r, config.PermWarehousesWrite)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+	id := principal.UserId
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&req)
+	if err != nil {
+		WriteError(w, common.Invalid(err))
+		return
+	}
+
+	err = h.Validate.Struct(&req)
+	if err != nil {
+		WriteError(w, h.Validate.Invalid(err, r.Header.Get("Accept-Language")))
+		return
+	}
+
+	response, err := h.Service.TransferStock(r.Context(), req, id)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	recordAudit(h.Audit, r, id, model.AuditCreate, model.AuditEntityStockTransfer, response.Id, nil, response)
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
+
+// AllocateStock implements WarehouseHandler
+func (h *warehouseHandler) AllocateStock(w http.ResponseWriter, r *http.Request) {
+	var req model.StockAllocationReq
+
+	principal, err := permittedPrincipal(r, config.PermWarehousesWrite)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	err = decoder.Decode(&req)
+	if err != nil {
+		WriteError(w, common.Invalid(err))
+		return
+	}
+
+	err = h.Validate.Struct(&req)
+	if err != nil {
+		WriteError(w, h.Validate.Invalid(err, r.Header.Get("Accept-Language")))
+		return
+	}
+
+	response, err := h.Service.AllocateStock(r.Context(), req, principal.UserId)
+	if err != nil {
+		WriteError(w, err)
+		return
+	}
+
+	recordAudit(h.Audit, r, principal.UserId, model.AuditAllocate, model.AuditEntityInventoryMovement, response.Movement.Id, nil, response)
+
+	WriteDataResponse(w, http.StatusOK, response)
+}
@@ -1,89 +1,237 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"learn/config"
 	"learn/handler"
+	"learn/migrations"
 	"learn/repository"
 	"learn/service"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
-	"github.com/go-chi/chi/middleware"
-	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
-	"github.com/go-playground/validator/v10"
-	"github.com/joho/godotenv"
 )
 
 func main() {
-	err := godotenv.Load()
+	cfg, err := config.Load()
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		fatal("load config", err)
 	}
 
-	db := config.ConnectDb()
-	validate := validator.New()
+	logLevel, err := cfg.Log.SlogLevel()
+	if err != nil {
+		fatal("load config", err)
+	}
+	// Everything, including the standard log package, logs JSON lines that
+	// say which request they belong to.
+	logger := slog.New(handler.NewLogHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel})))
+	slog.SetDefault(logger)
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err = runMigrate(cfg, os.Args[2:])
+		if err != nil {
+			fatal("migrate", err)
+		}
+		return
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		fatal("load config", err)
+	}
+
+	db := config.ConnectDb(cfg.Database, logger)
+	sqlDB, err := db.DB()
+	if err != nil {
+		fatal("connect database", err)
+	}
+
+	// The schema only changes through `migrate up`; refuse to serve a
+	// database that is behind the code.
+	migrator, err := config.NewMigrator(db, migrations.Files)
+	if err != nil {
+		fatal("load migrations", err)
+	}
+	pending, err := migrator.Pending(context.Background())
+	if err != nil {
+		fatal("check migrations", err)
+	}
+	if len(pending) > 0 {
+		fatal("check migrations", fmt.Errorf("database has %d pending migrations, run `migrate up` first", len(pending)))
+	}
+
+	validate, err := config.NewValidator()
+	if err != nil {
+		fatal("create validator", err)
+	}
+	tokens := config.NewTokens(cfg.JWT.Key)
+
+	// AUDIT
+	auditRepo := repository.NewAuditRepository(db)
+	auditService := service.NewAuditService(auditRepo)
+	auditHandler := handler.NewAuditHandler(auditService)
 	// USER
 	userRepo := repository.NewUserRepository(db)
-	userService := service.NewUserService(userRepo)
-	userHandler := handler.NewUserHandler(userService, validate)
+	userService := service.NewUserService(userRepo, tokens, cfg.Admin.RegistrationCode)
+	userHandler := handler.NewUserHandler(userService, auditService, validate)
 	// ADDRESS
 	addresRepo := repository.NewAddressRepository(db)
 	addressService := service.NewAddressService(&addresRepo)
-	addressHandler := handler.NewAddressHandler(addressService, validate)
-	// PRODUCT
+	addressHandler := handler.NewAddressHandler(addressService, auditService, validate)
+	// TAX
+	taxRepo := repository.NewTaxRepository(db)
+	taxService := service.NewTaxService(taxRepo)
+	taxHandler := handler.NewTaxHandler(taxService, validate)
+	// EXCHANGE RATE
+	exchangeRateRepo := repository.NewExchangeRateRepository(db)
+	exchangeRateService := service.NewExchangeRateService(exchangeRateRepo)
+	exchangeRateHandler := handler.NewExchangeRateHandler(exchangeRateService, validate)
+	// NOTIFICATION
 	productRepo := repository.NewProductRepository(db)
-	productService := service.NewProductService(productRepo)
-	productHandler := handler.NewProductHandler(productService, validate)
+	notificationRepo := repository.NewNotificationRepository(db)
+	notificationService := service.NewNotificationService(notificationRepo, userRepo, productRepo, newMailer(cfg.SMTP))
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+	// INVENTORY
+	inventoryRepo := repository.NewInventoryRepository(db)
+	inventoryService := service.NewInventoryService(inventoryRepo, notificationService)
+	inventoryHandler := handler.NewInventoryHandler(inventoryService, auditService, validate)
+	// WAREHOUSE
+	warehouseRepo := repository.NewWarehouseRepository(db)
+	warehouseService := service.NewWarehouseService(warehouseRepo, addresRepo)
+	warehouseHandler := handler.NewWarehouseHandler(warehouseService, auditService, validate)
+	// PRODUCT
+	imageStore := service.NewDiskImageStore(cfg.Storage.ImageDir)
+	productService := service.NewProductService(productRepo, taxRepo, exchangeRateRepo, inventoryService, imageStore)
+	productHandler := handler.NewProductHandler(productService, auditService, validate, cfg.AppURL)
 
-	r := chi.NewRouter()
+	productImportRepo := repository.NewProductImportRepository(db)
+	productImportService := service.NewProductImportService(productImportRepo, productRepo, productService, validate)
+	productImportHandler := handler.NewProductImportHandler(productImportService, auditService)
+	// HEALTH
+	healthHandler := handler.NewHealthHandler(
+		handler.HealthCheck{Name: "database", Check: sqlDB.PingContext},
+		handler.HealthCheck{Name: "storage", Check: func(ctx context.Context) error { return imageStore.Writable() }},
+	)
 
-	r.Use(cors.Handler(cors.Options{
+	router := handler.NewRouter(handler.Handlers{
+		User:          userHandler,
+		Address:       addressHandler,
+		Product:       productHandler,
+		ProductImport: productImportHandler,
+		Inventory:     inventoryHandler,
+		Warehouse:     warehouseHandler,
+		Tax:           taxHandler,
+		ExchangeRate:  exchangeRateHandler,
+		Audit:         auditHandler,
+		Notification:  notificationHandler,
+		Health:        healthHandler,
+	}, tokens, cfg.Server.RequestTimeout)
+
+	// CORS wraps the whole router so preflight requests are answered before
+	// authentication, and browsers can send and read the request id.
+	withCORS := cors.Handler(cors.Options{
 		// AllowedOrigins:   []string{"https://foo.com"}, // Use this to allow specific origin hosts
 		AllowedOrigins: []string{"https://*", "http://*"},
 		// AllowOriginFunc:  func(r *http.Request, origin string) bool { return true },
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", handler.RequestIdHeader},
+		ExposedHeaders:   []string{"Link", handler.RequestIdHeader},
 		AllowCredentials: false,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
-	}))
+	})
 
-	router := chi.NewRouter()
-	router.Use(middleware.RealIP)
-	router.Use(middleware.Logger)
+	// The first SIGINT or SIGTERM starts a graceful shutdown; stop() hands
+	// the signals back so a second one kills the process.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// USER
-	// Public
-	router.Post("/register", userHandler.Register)
-	router.Post("/login", userHandler.Login)
-	// Auth
-	router.Get("/profile", handler.Auth(userHandler.Profile))
-	router.Post("/change-password", handler.Auth(userHandler.ChangePassword))
+	var jobs sync.WaitGroup
+	jobs.Add(2)
+	go func() {
+		defer jobs.Done()
+		service.RunLowStockChecker(ctx, notificationService, cfg.Jobs.LowStockCheckInterval)
+	}()
+	go func() {
+		defer jobs.Done()
+		service.RunTrashPurger(ctx, productService, cfg.Jobs.TrashRetention, cfg.Jobs.TrashPurgeInterval)
+	}()
 
-	// ADMIN
-	router.Post("/register-admin", userHandler.RegisterAdmin)
+	server := &http.Server{
+		Addr:              cfg.Server.Addr,
+		Handler:           withCORS(router),
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
 
-	// ADDRESS
-	router.Post("/{user-id}/addresses", handler.Auth(addressHandler.AddAddress))
-	router.Get("/{user-id}/addresses", handler.Auth(addressHandler.GetAddresses))
-	router.Put("/{user-id}/addresses/{address-id}", handler.Auth(addressHandler.UpdateAddress))
-	router.Delete("/{user-id}/addresses/{address-id}", handler.Auth(addressHandler.DeleteAddress))
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+	slog.Info("listening", "addr", cfg.Server.Addr)
 
-	// PRODUCT
-	// ADMIN
-	router.Post("/{role}/products", handler.Auth(productHandler.AddProduct))
-	router.Get("/{role}/products/{product-id}", handler.Auth(productHandler.FindProductById))
-	router.Post("/{role}/products/{product-id}", handler.Auth(productHandler.UpdateProduct))
-	router.Delete("/{role}/products/{product-id}", handler.Auth(productHandler.DeleteProduct))
+	select {
+	case err = <-serveErr:
+		slog.Error("server failed", "error", err)
+	case <-ctx.Done():
+		slog.Info("shutting down")
+	}
+	stop()
 
-	// PRODUCT IMAGES
-	router.Get("/{role}/products/{product-id}/images", handler.Auth(productHandler.GetAllProductImagesByProductId))
-	router.Post("/{role}/products/{product-id}/images", handler.Auth(productHandler.UploadProductImage))
-	router.Delete("/{role}/products/{product-id}/images/{product-image-id}", handler.Auth(productHandler.DeleteProductImage))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
 
-	// USER
-	router.Get("/products", productHandler.FindAllProduct)
+	shutdownErr := server.Shutdown(shutdownCtx)
+	if shutdownErr != nil {
+		slog.Error("server shutdown failed", "error", shutdownErr)
+	}
+	waitFor(shutdownCtx, "background jobs", jobs.Wait)
+	waitFor(shutdownCtx, "product imports", productImportService.Wait)
+
+	closeErr := sqlDB.Close()
+	if closeErr != nil {
+		slog.Error("close database failed", "error", closeErr)
+	}
+
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// waitFor calls wait and returns when it does or when ctx is done, whichever
+// comes first.
+func waitFor(ctx context.Context, name string, wait func()) {
+	done := make(chan struct{})
+	go func() {
+		wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.Warn("shutdown timed out", "waiting_for", name)
+	}
+}
+
+// fatal logs err and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// newMailer uses SMTP when a host is configured and logs mail otherwise.
+func newMailer(cfg config.SMTPConfig) service.Mailer {
+	if cfg.Host == "" {
+		return service.NewLogMailer()
+	}
 
-	http.ListenAndServe(":3000", router)
+	return service.NewSMTPMailer(cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.From)
 }
@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS
+	audit_logs,
+	image_files,
+	product_import_jobs,
+	product_slugs,
+	stock_transfers,
+	warehouse_stocks,
+	warehouses,
+	stock_subscriptions,
+	notifications,
+	inventory_movements,
+	exchange_rates,
+	product_images,
+	products,
+	tax_classes,
+	addresses,
+	users;
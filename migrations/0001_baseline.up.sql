@@ -0,0 +1,215 @@
+-- Baseline: the schema AutoMigrate produced before migrations were
+-- versioned. Tables and columns are added only when missing, so a database
+-- AutoMigrate built at any earlier point adopts this version with its data
+-- in place. Rows from before a column existed are backfilled below, as
+-- startup used to do.
+
+CREATE TABLE IF NOT EXISTS users (id bigserial PRIMARY KEY);
+ALTER TABLE users
+	ADD COLUMN IF NOT EXISTS username text,
+	ADD COLUMN IF NOT EXISTS email text,
+	ADD COLUMN IF NOT EXISTS phone text,
+	ADD COLUMN IF NOT EXISTS password text,
+	ADD COLUMN IF NOT EXISTS role text,
+	ADD COLUMN IF NOT EXISTS created_at timestamptz,
+	ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS addresses (id bigserial PRIMARY KEY);
+ALTER TABLE addresses
+	ADD COLUMN IF NOT EXISTS address text,
+	ADD COLUMN IF NOT EXISTS postal_code text,
+	ADD COLUMN IF NOT EXISTS is_primary text,
+	ADD COLUMN IF NOT EXISTS latitude decimal,
+	ADD COLUMN IF NOT EXISTS longitude decimal,
+	ADD COLUMN IF NOT EXISTS user_id bigint,
+	ADD COLUMN IF NOT EXISTS created_at timestamptz,
+	ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS tax_classes (id bigserial PRIMARY KEY);
+ALTER TABLE tax_classes
+	ADD COLUMN IF NOT EXISTS code text,
+	ADD COLUMN IF NOT EXISTS name text,
+	ADD COLUMN IF NOT EXISTS rate bigint,
+	ADD COLUMN IF NOT EXISTS created_at timestamptz,
+	ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS products (id bigserial PRIMARY KEY);
+ALTER TABLE products
+	ADD COLUMN IF NOT EXISTS sku text,
+	ADD COLUMN IF NOT EXISTS barcode text,
+	ADD COLUMN IF NOT EXISTS name text,
+	ADD COLUMN IF NOT EXISTS slug text,
+	ADD COLUMN IF NOT EXISTS meta_title text,
+	ADD COLUMN IF NOT EXISTS meta_description text,
+	ADD COLUMN IF NOT EXISTS description text,
+	ADD COLUMN IF NOT EXISTS quantity bigint,
+	ADD COLUMN IF NOT EXISTS low_stock_threshold bigint,
+	ADD COLUMN IF NOT EXISTS low_stock_alerted_at timestamptz,
+	ADD COLUMN IF NOT EXISTS price_amount bigint,
+	ADD COLUMN IF NOT EXISTS price_currency varchar(3),
+	ADD COLUMN IF NOT EXISTS tax_class_id bigint,
+	ADD COLUMN IF NOT EXISTS tax_inclusive boolean,
+	ADD COLUMN IF NOT EXISTS created_at timestamptz,
+	ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+	ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+-- Products created before slugs existed get one from their name, made
+-- unique with the id.
+UPDATE products SET slug = TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER(name), '[^a-z0-9]+', '-', 'g')) || '-' || id
+WHERE slug IS NULL OR slug = '';
+
+-- deleted_at used to be a plain timestamp, so live rows hold the zero time
+-- instead of NULL and would look deleted.
+UPDATE products SET deleted_at = NULL WHERE deleted_at < '0002-01-01';
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products (sku) WHERE sku <> '';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_barcode ON products (barcode) WHERE barcode <> '';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_slug ON products (slug);
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at);
+
+CREATE TABLE IF NOT EXISTS product_images (id bigserial PRIMARY KEY);
+ALTER TABLE product_images
+	ADD COLUMN IF NOT EXISTS product_id bigint,
+	ADD COLUMN IF NOT EXISTS file_name text,
+	ADD COLUMN IF NOT EXISTS content_hash varchar(64),
+	ADD COLUMN IF NOT EXISTS is_primary text,
+	ADD COLUMN IF NOT EXISTS position bigint,
+	ADD COLUMN IF NOT EXISTS alt_text text,
+	ADD COLUMN IF NOT EXISTS created_at timestamptz,
+	ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+	ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+-- Images uploaded before galleries could be ordered keep their upload
+-- order, after any image that already has a position.
+UPDATE product_images SET position = ordered.position
+FROM (
+	SELECT id, COALESCE(MAX(position) OVER (PARTITION BY product_id), 0)
+		+ ROW_NUMBER() OVER (PARTITION BY product_id, position IS NULL ORDER BY id) AS position
+	FROM product_images
+) AS ordered
+WHERE product_images.id = ordered.id AND product_images.position IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_product_images_content_hash ON product_images (content_hash);
+CREATE INDEX IF NOT EXISTS idx_product_images_deleted_at ON product_images (deleted_at);
+
+CREATE TABLE IF NOT EXISTS exchange_rates (id bigserial PRIMARY KEY);
+ALTER TABLE exchange_rates
+	ADD COLUMN IF NOT EXISTS currency varchar(3),
+	ADD COLUMN IF NOT EXISTS rate decimal,
+	ADD COLUMN IF NOT EXISTS source text,
+	ADD COLUMN IF NOT EXISTS created_at timestamptz,
+	ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exchange_rates_currency ON exchange_rates (currency);
+
+CREATE TABLE IF NOT EXISTS inventory_movements (id bigserial PRIMARY KEY);
+ALTER TABLE inventory_movements
+	ADD COLUMN IF NOT EXISTS product_id bigint,
+	ADD COLUMN IF NOT EXISTS warehouse_id bigint,
+	ADD COLUMN IF NOT EXISTS type text,
+	ADD COLUMN IF NOT EXISTS quantity bigint,
+	ADD COLUMN IF NOT EXISTS reason text,
+	ADD COLUMN IF NOT EXISTS balance_after bigint,
+	ADD COLUMN IF NOT EXISTS user_id bigint,
+	ADD COLUMN IF NOT EXISTS created_at timestamptz;
+
+-- Stock from before the inventory ledger gets an opening balance entry so
+-- reconciliation starts from a clean state. user_id 0 marks the system.
+INSERT INTO inventory_movements (product_id, type, quantity, reason, balance_after, user_id, created_at)
+SELECT id, 'adjustment', quantity, 'opening balance', quantity, 0, NOW()
+FROM products
+WHERE quantity <> 0
+	AND NOT EXISTS (SELECT 1 FROM inventory_movements WHERE inventory_movements.product_id = products.id);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_product_id ON inventory_movements (product_id);
+
+CREATE TABLE IF NOT EXISTS notifications (id bigserial PRIMARY KEY);
+ALTER TABLE notifications
+	ADD COLUMN IF NOT EXISTS user_id bigint,
+	ADD COLUMN IF NOT EXISTS type text,
+	ADD COLUMN IF NOT EXISTS product_id bigint,
+	ADD COLUMN IF NOT EXISTS message text,
+	ADD COLUMN IF NOT EXISTS read_at timestamptz,
+	ADD COLUMN IF NOT EXISTS created_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications (user_id);
+
+CREATE TABLE IF NOT EXISTS stock_subscriptions (id bigserial PRIMARY KEY);
+ALTER TABLE stock_subscriptions
+	ADD COLUMN IF NOT EXISTS product_id bigint,
+	ADD COLUMN IF NOT EXISTS user_id bigint,
+	ADD COLUMN IF NOT EXISTS notified_at timestamptz,
+	ADD COLUMN IF NOT EXISTS created_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_stock_subscriptions_product_id ON stock_subscriptions (product_id);
+
+CREATE TABLE IF NOT EXISTS warehouses (id bigserial PRIMARY KEY);
+ALTER TABLE warehouses
+	ADD COLUMN IF NOT EXISTS code text,
+	ADD COLUMN IF NOT EXISTS name text,
+	ADD COLUMN IF NOT EXISTS address text,
+	ADD COLUMN IF NOT EXISTS latitude decimal,
+	ADD COLUMN IF NOT EXISTS longitude decimal,
+	ADD COLUMN IF NOT EXISTS created_at timestamptz,
+	ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouses_code ON warehouses (code);
+
+CREATE TABLE IF NOT EXISTS warehouse_stocks (id bigserial PRIMARY KEY);
+ALTER TABLE warehouse_stocks
+	ADD COLUMN IF NOT EXISTS warehouse_id bigint,
+	ADD COLUMN IF NOT EXISTS product_id bigint,
+	ADD COLUMN IF NOT EXISTS quantity bigint,
+	ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouse_stock ON warehouse_stocks (warehouse_id, product_id);
+
+CREATE TABLE IF NOT EXISTS stock_transfers (id bigserial PRIMARY KEY);
+ALTER TABLE stock_transfers
+	ADD COLUMN IF NOT EXISTS product_id bigint,
+	ADD COLUMN IF NOT EXISTS from_warehouse_id bigint,
+	ADD COLUMN IF NOT EXISTS to_warehouse_id bigint,
+	ADD COLUMN IF NOT EXISTS quantity bigint,
+	ADD COLUMN IF NOT EXISTS user_id bigint,
+	ADD COLUMN IF NOT EXISTS created_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_stock_transfers_product_id ON stock_transfers (product_id);
+
+CREATE TABLE IF NOT EXISTS product_slugs (id bigserial PRIMARY KEY);
+ALTER TABLE product_slugs
+	ADD COLUMN IF NOT EXISTS product_id bigint,
+	ADD COLUMN IF NOT EXISTS slug text,
+	ADD COLUMN IF NOT EXISTS created_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_product_slugs_product_id ON product_slugs (product_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_slugs_slug ON product_slugs (slug);
+
+CREATE TABLE IF NOT EXISTS product_import_jobs (id bigserial PRIMARY KEY);
+ALTER TABLE product_import_jobs
+	ADD COLUMN IF NOT EXISTS user_id bigint,
+	ADD COLUMN IF NOT EXISTS file_name text,
+	ADD COLUMN IF NOT EXISTS dry_run boolean,
+	ADD COLUMN IF NOT EXISTS status text,
+	ADD COLUMN IF NOT EXISTS total_rows bigint,
+	ADD COLUMN IF NOT EXISTS created_rows bigint,
+	ADD COLUMN IF NOT EXISTS updated_rows bigint,
+	ADD COLUMN IF NOT EXISTS failed_rows bigint,
+	ADD COLUMN IF NOT EXISTS errors text,
+	ADD COLUMN IF NOT EXISTS created_at timestamptz,
+	ADD COLUMN IF NOT EXISTS updated_at timestamptz,
+	ADD COLUMN IF NOT EXISTS finished_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS image_files (hash varchar(64) PRIMARY KEY);
+ALTER TABLE image_files
+	ADD COLUMN IF NOT EXISTS file_name text,
+	ADD COLUMN IF NOT EXISTS size bigint,
+	ADD COLUMN IF NOT EXISTS ref_count bigint,
+	ADD COLUMN IF NOT EXISTS created_at timestamptz,
+	ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS audit_logs (id bigserial PRIMARY KEY);
+ALTER TABLE audit_logs
+	ADD COLUMN IF NOT EXISTS user_id bigint,
+	ADD COLUMN IF NOT EXISTS action varchar(32),
+	ADD COLUMN IF NOT EXISTS entity_type varchar(32),
+	ADD COLUMN IF NOT EXISTS entity_id bigint,
+	ADD COLUMN IF NOT EXISTS before jsonb,
+	ADD COLUMN IF NOT EXISTS after jsonb,
+	ADD COLUMN IF NOT EXISTS ip varchar(64),
+	ADD COLUMN IF NOT EXISTS created_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user_id ON audit_logs (user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_entity ON audit_logs (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
@@ -0,0 +1,21 @@
+DROP INDEX IF EXISTS
+	idx_product_import_jobs_user_id,
+	idx_warehouse_stocks_product_id,
+	idx_product_images_product_id,
+	idx_stock_subscriptions_user_id,
+	idx_addresses_user_id;
+
+ALTER TABLE warehouse_stocks
+	DROP CONSTRAINT IF EXISTS fk_warehouse_stocks_warehouse,
+	DROP CONSTRAINT IF EXISTS fk_warehouse_stocks_product;
+ALTER TABLE stock_transfers DROP CONSTRAINT IF EXISTS fk_stock_transfers_product;
+ALTER TABLE inventory_movements DROP CONSTRAINT IF EXISTS fk_inventory_movements_product;
+ALTER TABLE product_slugs DROP CONSTRAINT IF EXISTS fk_product_slugs_product;
+ALTER TABLE product_images DROP CONSTRAINT IF EXISTS fk_product_images_product;
+ALTER TABLE stock_subscriptions
+	DROP CONSTRAINT IF EXISTS fk_stock_subscriptions_product,
+	DROP CONSTRAINT IF EXISTS fk_stock_subscriptions_user;
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS fk_notifications_user;
+ALTER TABLE addresses DROP CONSTRAINT IF EXISTS fk_addresses_user;
+
+DROP INDEX IF EXISTS idx_users_email, idx_users_username;
@@ -0,0 +1,97 @@
+-- Rows left behind by deletes the schema did not cascade, such as the
+-- ledger and slug history of purged products, can't satisfy the new
+-- foreign keys. They are reported instead of deleted, since some are
+-- records worth keeping elsewhere first.
+DO $$
+DECLARE
+	ref record;
+	orphans bigint;
+	ids text;
+	problems text[] := '{}';
+BEGIN
+	FOR ref IN
+		SELECT * FROM (VALUES
+			('addresses', 'user_id', 'users'),
+			('notifications', 'user_id', 'users'),
+			('stock_subscriptions', 'user_id', 'users'),
+			('stock_subscriptions', 'product_id', 'products'),
+			('product_images', 'product_id', 'products'),
+			('product_slugs', 'product_id', 'products'),
+			('inventory_movements', 'product_id', 'products'),
+			('stock_transfers', 'product_id', 'products'),
+			('warehouse_stocks', 'product_id', 'products'),
+			('warehouse_stocks', 'warehouse_id', 'warehouses')
+		) AS refs (child, child_column, parent)
+	LOOP
+		EXECUTE format(
+			'SELECT count(*), array_to_string((array_agg(id ORDER BY id))[1:10], '', '') FROM %I WHERE %I NOT IN (SELECT id FROM %I)',
+			ref.child, ref.child_column, ref.parent
+		) INTO orphans, ids;
+
+		IF orphans > 0 THEN
+			problems := problems || format('%s %s with a missing %s (first ids %s)', orphans, ref.child, ref.child_column, ids);
+		END IF;
+	END LOOP;
+
+	IF cardinality(problems) > 0 THEN
+		RAISE EXCEPTION 'rows refer to missing users, products or warehouses: %. Delete or fix them, then run the migration again.', array_to_string(problems, '; ');
+	END IF;
+END $$;
+
+-- Usernames and emails become unique. Duplicates are reported instead of
+-- guessed at, since either account may be in use.
+DO $$
+DECLARE
+	duplicates text;
+BEGIN
+	SELECT string_agg(format('%s %s (ids %s)', field, value, ids), ', ') INTO duplicates
+	FROM (
+		SELECT 'username' AS field, username AS value, string_agg(id::text, ', ' ORDER BY id) AS ids
+		FROM users
+		WHERE username IS NOT NULL
+		GROUP BY username
+		HAVING count(*) > 1
+		UNION ALL
+		SELECT 'email', email, string_agg(id::text, ', ' ORDER BY id)
+		FROM users
+		WHERE email IS NOT NULL
+		GROUP BY email
+		HAVING count(*) > 1
+	) AS duplicated;
+
+	IF duplicates IS NOT NULL THEN
+		RAISE EXCEPTION 'usernames and emails are not unique: %. Rename or merge the accounts, then run the migration again.', duplicates;
+	END IF;
+END $$;
+
+CREATE UNIQUE INDEX idx_users_username ON users (username);
+CREATE UNIQUE INDEX idx_users_email ON users (email);
+
+-- Columns where 0 means "none" (the user_id of system ledger entries,
+-- audit_logs.user_id) stay without a foreign key. products.tax_class_id
+-- gets one in 0003.
+ALTER TABLE addresses
+	ADD CONSTRAINT fk_addresses_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+ALTER TABLE notifications
+	ADD CONSTRAINT fk_notifications_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+ALTER TABLE stock_subscriptions
+	ADD CONSTRAINT fk_stock_subscriptions_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+	ADD CONSTRAINT fk_stock_subscriptions_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE;
+ALTER TABLE product_images
+	ADD CONSTRAINT fk_product_images_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE;
+ALTER TABLE product_slugs
+	ADD CONSTRAINT fk_product_slugs_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE;
+ALTER TABLE inventory_movements
+	ADD CONSTRAINT fk_inventory_movements_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE;
+ALTER TABLE stock_transfers
+	ADD CONSTRAINT fk_stock_transfers_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE;
+ALTER TABLE warehouse_stocks
+	ADD CONSTRAINT fk_warehouse_stocks_product FOREIGN KEY (product_id) REFERENCES products (id) ON DELETE CASCADE,
+	ADD CONSTRAINT fk_warehouse_stocks_warehouse FOREIGN KEY (warehouse_id) REFERENCES warehouses (id);
+
+-- Lookups by owner, which the cascades above also rely on.
+CREATE INDEX idx_addresses_user_id ON addresses (user_id);
+CREATE INDEX idx_stock_subscriptions_user_id ON stock_subscriptions (user_id);
+CREATE INDEX idx_product_images_product_id ON product_images (product_id);
+CREATE INDEX idx_warehouse_stocks_product_id ON warehouse_stocks (product_id);
+CREATE INDEX idx_product_import_jobs_user_id ON product_import_jobs (user_id);
@@ -0,0 +1,5 @@
+ALTER TABLE products DROP CONSTRAINT IF EXISTS fk_products_tax_class;
+
+UPDATE products SET tax_class_id = 0 WHERE tax_class_id IS NULL;
+
+DROP INDEX IF EXISTS idx_tax_classes_code;
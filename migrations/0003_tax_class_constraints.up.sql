@@ -0,0 +1,40 @@
+-- Tax class codes become unique. Duplicates are reported instead of
+-- guessed at, since products may refer to either copy.
+DO $$
+DECLARE
+	duplicates text;
+BEGIN
+	SELECT string_agg(format('%s (ids %s)', code, ids), ', ') INTO duplicates
+	FROM (
+		SELECT code, string_agg(id::text, ', ' ORDER BY id) AS ids
+		FROM tax_classes
+		GROUP BY code
+		HAVING count(*) > 1
+	) AS duplicated;
+
+	IF duplicates IS NOT NULL THEN
+		RAISE EXCEPTION 'tax class codes are not unique: %. Rename or merge them, then run the migration again.', duplicates;
+	END IF;
+END $$;
+
+CREATE UNIQUE INDEX idx_tax_classes_code ON tax_classes (code);
+
+-- Products without a tax class used to store 0; they now store NULL so
+-- tax_class_id can reference tax_classes.
+UPDATE products SET tax_class_id = NULL WHERE tax_class_id = 0;
+
+DO $$
+DECLARE
+	missing text;
+BEGIN
+	SELECT string_agg(format('product %s (tax class %s)', id, tax_class_id), ', ' ORDER BY id) INTO missing
+	FROM products
+	WHERE tax_class_id IS NOT NULL AND tax_class_id NOT IN (SELECT id FROM tax_classes);
+
+	IF missing IS NOT NULL THEN
+		RAISE EXCEPTION 'products refer to missing tax classes: %. Fix their tax class, then run the migration again.', missing;
+	END IF;
+END $$;
+
+ALTER TABLE products
+	ADD CONSTRAINT fk_products_tax_class FOREIGN KEY (tax_class_id) REFERENCES tax_classes (id);
@@ -0,0 +1,4 @@
+-- Only base currency prices fit the old whole rupiah column.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS price bigint;
+
+UPDATE products SET price = price_amount / 100 WHERE price_currency = 'IDR';
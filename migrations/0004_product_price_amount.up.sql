@@ -0,0 +1,29 @@
+-- Prices used to be whole rupiah in products.price. Databases from before
+-- the money columns still have it; their prices move to the minor-unit
+-- columns here. The migration runs in one transaction, so price is only
+-- dropped once every price has been moved, and a failure leaves it as it
+-- was.
+ALTER TABLE products
+	ADD COLUMN IF NOT EXISTS price_amount bigint,
+	ADD COLUMN IF NOT EXISTS price_currency varchar(3);
+
+DO $$
+DECLARE
+	unmoved bigint;
+BEGIN
+	IF NOT EXISTS (
+		SELECT 1 FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = 'products' AND column_name = 'price'
+	) THEN
+		RETURN;
+	END IF;
+
+	UPDATE products SET price_amount = price * 100, price_currency = 'IDR' WHERE price_currency IS NULL;
+
+	SELECT count(*) INTO unmoved FROM products WHERE price IS NOT NULL AND price_amount IS NULL;
+	IF unmoved > 0 THEN
+		RAISE EXCEPTION '% product prices could not be moved to price_amount; products.price is kept', unmoved;
+	END IF;
+
+	ALTER TABLE products DROP COLUMN price;
+END $$;
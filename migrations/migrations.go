@@ -0,0 +1,9 @@
+// Package migrations holds the versioned SQL migrations of the database
+// schema. Each version has a NNNN_name.up.sql file and a matching
+// NNNN_name.down.sql file that reverts it.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var Files embed.FS
@@ -4,21 +4,27 @@ import "time"
 
 // DATABASE
 type Address struct {
-	Id        int
-	Address   string
-	IsPrimary string
-	UserId    int
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	User      User
+	Id         int
+	Address    string
+	PostalCode string
+	IsPrimary  string
+	Latitude   *float64
+	Longitude  *float64
+	UserId     int
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	User       User
 }
 
 // Request
 type (
 	AddressReq struct {
-		Address   string `json:"address" validate:"required"`
-		IsPrimary bool   `json:"is_primary"`
-		UserId    int    `json:"user_id"`
+		Address    string   `json:"address" validate:"required"`
+		PostalCode string   `json:"postal_code" validate:"omitempty,postal_code"`
+		IsPrimary  bool     `json:"is_primary"`
+		Latitude   *float64 `json:"latitude" validate:"omitempty,latitude"`
+		Longitude  *float64 `json:"longitude" validate:"omitempty,longitude"`
+		UserId     int      `json:"user_id"`
 	}
 
 	GetAllAddress struct {
@@ -33,8 +39,12 @@ type (
 	}
 
 	AddressRes struct {
-		Address   string `json:"address"`
-		IsPrimary bool   `json:"is_primary"`
-		UserId    int    `json:"user_id"`
+		Id         int      `json:"id"`
+		Address    string   `json:"address"`
+		PostalCode string   `json:"postal_code"`
+		IsPrimary  bool     `json:"is_primary"`
+		Latitude   *float64 `json:"latitude"`
+		Longitude  *float64 `json:"longitude"`
+		UserId     int      `json:"user_id"`
 	}
 )
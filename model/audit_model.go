@@ -0,0 +1,153 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// Audit actions
+const (
+	AuditCreate         = "create"
+	AuditUpdate         = "update"
+	AuditDelete         = "delete"
+	AuditRestore        = "restore"
+	AuditReorder        = "reorder"
+	AuditImport         = "import"
+	AuditChangePassword = "change_password"
+	AuditAllocate       = "allocate"
+	AuditRegister       = "register" // a user creating their own account; the actor is the new user
+)
+
+// Audited entity types
+const (
+	AuditEntityProduct           = "product"
+	AuditEntityProductImage      = "product_image"
+	AuditEntityProductImport     = "product_import_job"
+	AuditEntityAddress           = "address"
+	AuditEntityUser              = "user"
+	AuditEntityInventoryMovement = "inventory_movement"
+	AuditEntityStockTransfer     = "stock_transfer"
+)
+
+// DATABASE
+// AuditLog is one change to the catalog or to a user's data. Before and
+// After hold only the fields that changed, so a create has no Before and a
+// delete no After.
+type AuditLog struct {
+	Id         int
+	UserId     int       `gorm:"index"`
+	Action     string    `gorm:"size:32"`
+	EntityType string    `gorm:"size:32;index:idx_audit_logs_entity"`
+	EntityId   int       `gorm:"index:idx_audit_logs_entity"`
+	Before     string    `gorm:"type:jsonb"`
+	After      string    `gorm:"type:jsonb"`
+	Ip         string    `gorm:"size:64"`
+	CreatedAt  time.Time `gorm:"index"`
+}
+
+// AuditEntry is a change to record. Before and After are the entity as it
+// was and as it is, in any form that marshals to JSON; nil when it did not
+// or no longer exists.
+type AuditEntry struct {
+	UserId     int
+	Action     string
+	EntityType string
+	EntityId   int
+	Before     interface{}
+	After      interface{}
+	Ip         string
+}
+
+// REQUEST
+type AuditLogFilter struct {
+	UserId     int
+	Action     string
+	EntityType string
+	EntityId   int
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+// RESPONSE
+type AuditLogRes struct {
+	Id         int             `json:"id"`
+	UserId     int             `json:"user_id"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityId   int             `json:"entity_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	Ip         string          `json:"ip"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// AuditDiff returns the JSON of the before and after states reduced to the
+// fields that differ. States that are not JSON objects are kept whole when
+// they differ.
+func AuditDiff(before interface{}, after interface{}) (string, string, error) {
+	beforeJSON, err := json.Marshal(before)
+	if err != nil {
+		return "", "", err
+	}
+
+	afterJSON, err := json.Marshal(after)
+	if err != nil {
+		return "", "", err
+	}
+
+	beforeFields := map[string]json.RawMessage{}
+	afterFields := map[string]json.RawMessage{}
+	if json.Unmarshal(beforeJSON, &beforeFields) != nil || json.Unmarshal(afterJSON, &afterFields) != nil {
+		if bytes.Equal(beforeJSON, afterJSON) {
+			return "null", "null", nil
+		}
+		return string(beforeJSON), string(afterJSON), nil
+	}
+
+	for field, value := range beforeFields {
+		if other, ok := afterFields[field]; ok && bytes.Equal(value, other) {
+			delete(beforeFields, field)
+			delete(afterFields, field)
+		}
+	}
+
+	beforeJSON, err = json.Marshal(beforeFields)
+	if err != nil {
+		return "", "", err
+	}
+
+	afterJSON, err = json.Marshal(afterFields)
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(beforeJSON), string(afterJSON), nil
+}
+
+// Formatter Response
+func AuditLogFormatRes(log AuditLog) AuditLogRes {
+	return AuditLogRes{
+		Id:         log.Id,
+		UserId:     log.UserId,
+		Action:     log.Action,
+		EntityType: log.EntityType,
+		EntityId:   log.EntityId,
+		Before:     json.RawMessage(log.Before),
+		After:      json.RawMessage(log.After),
+		Ip:         log.Ip,
+		CreatedAt:  log.CreatedAt,
+	}
+}
+
+func AuditLogsFormatRes(logs []AuditLog) []AuditLogRes {
+	response := []AuditLogRes{}
+
+	for _, log := range logs {
+		response = append(response, AuditLogFormatRes(log))
+	}
+
+	return response
+}
@@ -0,0 +1,33 @@
+package model
+
+// ValidBarcode reports whether code is an EAN-8, UPC-A or EAN-13 number
+// with a correct GS1 check digit.
+func ValidBarcode(code string) bool {
+	switch len(code) {
+	case 8, 12, 13:
+	default:
+		return false
+	}
+
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return BarcodeCheckDigit(code[:len(code)-1]) == code[len(code)-1]
+}
+
+// BarcodeCheckDigit returns the GS1 check digit for the digits of a
+// barcode without its check digit. Weights alternate 3 and 1 starting from
+// the rightmost digit.
+func BarcodeCheckDigit(digits string) byte {
+	sum := 0
+	weight := 3
+	for i := len(digits) - 1; i >= 0; i-- {
+		sum += int(digits[i]-'0') * weight
+		weight = 4 - weight
+	}
+
+	return byte('0' + (10-sum%10)%10)
+}
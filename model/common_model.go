@@ -3,3 +3,8 @@ package model
 type MessageResponse struct {
 	Message string `json:"message"`
 }
+
+type HealthRes struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
@@ -0,0 +1,130 @@
+package model
+
+import (
+	"fmt"
+	"learn/common"
+	"math"
+	"time"
+)
+
+// DATABASE
+type ExchangeRate struct {
+	Id        int
+	Currency  string  `gorm:"uniqueIndex;size:3"`
+	Rate      float64 // BaseCurrency major units per one major unit of Currency
+	Source    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// REQUEST
+type (
+	ExchangeRateReq struct {
+		Currency string  `json:"currency" validate:"required,len=3"`
+		Rate     float64 `json:"rate" validate:"required,gt=0"`
+	}
+)
+
+// RESPONSE
+type (
+	ExchangeRateRes struct {
+		Currency  string    `json:"currency"`
+		Rate      float64   `json:"rate"`
+		Source    string    `json:"source"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+
+	DisplayPriceRes struct {
+		Price         Money     `json:"price"`
+		Rate          float64   `json:"rate"`
+		RateUpdatedAt time.Time `json:"rate_updated_at"`
+	}
+)
+
+// ToBase converts m into BaseCurrency using the rate of m's currency.
+func (rate ExchangeRate) ToBase(m Money) Money {
+	return convertMoney(m, rate.Rate, BaseCurrency)
+}
+
+// FromBase converts a BaseCurrency amount into the rate's currency.
+func (rate ExchangeRate) FromBase(m Money) Money {
+	return convertMoney(m, 1/rate.Rate, rate.Currency)
+}
+
+// convertMoney multiplies the major-unit value of m by factor and returns it
+// in the minor units of currency.
+func convertMoney(m Money, factor float64, currency string) Money {
+	major := float64(m.Amount) / math.Pow10(CurrencyExponent(m.Currency))
+	minor := math.Round(major * factor * math.Pow10(CurrencyExponent(currency)))
+
+	return NewMoney(int64(minor), currency)
+}
+
+// Formatter Response
+func ExchangeRateFormatRes(rate ExchangeRate) ExchangeRateRes {
+	return ExchangeRateRes{
+		Currency:  rate.Currency,
+		Rate:      rate.Rate,
+		Source:    rate.Source,
+		UpdatedAt: rate.UpdatedAt,
+	}
+}
+
+func ExchangeRatesFormatRes(rates []ExchangeRate) []ExchangeRateRes {
+	exchangeRatesFormatRes := []ExchangeRateRes{}
+
+	for _, rate := range rates {
+		exchangeRatesFormatRes = append(exchangeRatesFormatRes, ExchangeRateFormatRes(rate))
+	}
+
+	return exchangeRatesFormatRes
+}
+
+// ConvertPrice converts price into currency for display, going through
+// BaseCurrency when neither side is the base. rates is keyed by currency.
+func ConvertPrice(price Money, currency string, rates map[string]ExchangeRate) (DisplayPriceRes, error) {
+	if !IsSupportedCurrency(currency) {
+		return DisplayPriceRes{}, fmt.Errorf("currency %q: %w", currency, common.ErrUnsupportedCurrency)
+	}
+
+	if price.Currency == currency {
+		return DisplayPriceRes{Price: price, Rate: 1}, nil
+	}
+
+	base := price
+	factor := 1.0
+	var updatedAt time.Time
+
+	if price.Currency != BaseCurrency {
+		rate, ok := rates[price.Currency]
+		if !ok {
+			return DisplayPriceRes{}, fmt.Errorf("exchange rate %s: %w", price.Currency, common.ErrNotFound)
+		}
+
+		base = rate.ToBase(price)
+		factor = rate.Rate
+		updatedAt = rate.UpdatedAt
+	}
+
+	converted := base
+	if currency != BaseCurrency {
+		rate, ok := rates[currency]
+		if !ok {
+			return DisplayPriceRes{}, fmt.Errorf("exchange rate %s: %w", currency, common.ErrNotFound)
+		}
+
+		converted = rate.FromBase(base)
+		factor = factor / rate.Rate
+		if updatedAt.IsZero() || rate.UpdatedAt.Before(updatedAt) {
+			updatedAt = rate.UpdatedAt
+		}
+	}
+
+	response := DisplayPriceRes{
+		Price:         converted,
+		Rate:          factor,
+		RateUpdatedAt: updatedAt,
+	}
+
+	return response, nil
+}
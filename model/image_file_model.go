@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// DATABASE
+// ImageFile is an uploaded image stored once under its SHA-256 content
+// hash. RefCount is the number of ProductImage rows, trashed ones
+// included, that use the file.
+type ImageFile struct {
+	Hash      string `gorm:"primaryKey;size:64"`
+	FileName  string
+	Size      int64
+	RefCount  int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,112 @@
+package model
+
+import "time"
+
+// Inventory movement types
+const (
+	MovementRestock      = "restock"
+	MovementSale         = "sale"
+	MovementCancellation = "cancellation"
+	MovementAdjustment   = "adjustment"
+	MovementReturn       = "return"
+)
+
+// DATABASE
+type (
+	// InventoryMovement is an append-only ledger entry. Quantity is the signed
+	// change in stock and BalanceAfter the product quantity once applied.
+	InventoryMovement struct {
+		Id           int
+		ProductId    int `gorm:"index"`
+		WarehouseId  int
+		Type         string
+		Quantity     int
+		Reason       string
+		BalanceAfter int
+		UserId       int
+		CreatedAt    time.Time
+	}
+
+	StockDrift struct {
+		ProductId      int
+		Name           string
+		Quantity       int
+		LedgerQuantity int
+	}
+)
+
+// REQUEST
+type (
+	InventoryMovementReq struct {
+		Type        string `json:"type" validate:"required,oneof=restock sale cancellation adjustment return"`
+		Quantity    int    `json:"quantity" validate:"required"`
+		Reason      string `json:"reason" validate:"required_if=Type adjustment"`
+		WarehouseId int    `json:"warehouse_id"`
+	}
+)
+
+// RESPONSE
+type (
+	InventoryMovementRes struct {
+		Id           int       `json:"id"`
+		ProductId    int       `json:"product_id"`
+		WarehouseId  int       `json:"warehouse_id"`
+		Type         string    `json:"type"`
+		Quantity     int       `json:"quantity"`
+		Reason       string    `json:"reason"`
+		BalanceAfter int       `json:"balance_after"`
+		UserId       int       `json:"user_id"`
+		CreatedAt    time.Time `json:"created_at"`
+	}
+
+	StockDriftRes struct {
+		ProductId      int    `json:"product_id"`
+		Name           string `json:"name"`
+		Quantity       int    `json:"quantity"`
+		LedgerQuantity int    `json:"ledger_quantity"`
+		Drift          int    `json:"drift"`
+	}
+)
+
+// Formatter Response
+func InventoryMovementFormatRes(movement InventoryMovement) InventoryMovementRes {
+	return InventoryMovementRes{
+		Id:           movement.Id,
+		ProductId:    movement.ProductId,
+		WarehouseId:  movement.WarehouseId,
+		Type:         movement.Type,
+		Quantity:     movement.Quantity,
+		Reason:       movement.Reason,
+		BalanceAfter: movement.BalanceAfter,
+		UserId:       movement.UserId,
+		CreatedAt:    movement.CreatedAt,
+	}
+}
+
+func InventoryMovementsFormatRes(movements []InventoryMovement) []InventoryMovementRes {
+	inventoryMovementsFormatRes := []InventoryMovementRes{}
+
+	for _, movement := range movements {
+		inventoryMovementsFormatRes = append(inventoryMovementsFormatRes, InventoryMovementFormatRes(movement))
+	}
+
+	return inventoryMovementsFormatRes
+}
+
+func StockDriftsFormatRes(drifts []StockDrift) []StockDriftRes {
+	stockDriftsFormatRes := []StockDriftRes{}
+
+	for _, drift := range drifts {
+		stockDriftFormatRes := StockDriftRes{
+			ProductId:      drift.ProductId,
+			Name:           drift.Name,
+			Quantity:       drift.Quantity,
+			LedgerQuantity: drift.LedgerQuantity,
+			Drift:          drift.Quantity - drift.LedgerQuantity,
+		}
+
+		stockDriftsFormatRes = append(stockDriftsFormatRes, stockDriftFormatRes)
+	}
+
+	return stockDriftsFormatRes
+}
@@ -0,0 +1,189 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"learn/common"
+	"strconv"
+	"strings"
+)
+
+// BaseCurrency is the currency orders are settled in.
+const BaseCurrency = "IDR"
+
+// currencyExponents lists the supported ISO 4217 currencies and the number
+// of minor units in one major unit, as a power of ten.
+var currencyExponents = map[string]int{
+	"IDR": 2,
+	"USD": 2,
+	"EUR": 2,
+	"SGD": 2,
+	"MYR": 2,
+	"AUD": 2,
+	"JPY": 0,
+}
+
+// Money is an amount in the minor units of an ISO 4217 currency. It is stored
+// as two columns through gorm's embedded fields, e.g. price_amount and
+// price_currency.
+type Money struct {
+	Amount   int64  `json:"amount" gorm:"column:amount" validate:"gte=0"`
+	Currency string `json:"currency" gorm:"column:currency;size:3"`
+}
+
+type moneyJSON struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	Display  string `json:"display,omitempty"`
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney reads a decimal amount in major units, e.g. "15000.50", into
+// Money. More decimals than the currency has are rejected rather than
+// rounded.
+func ParseMoney(amount string, currency string) (Money, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		currency = BaseCurrency
+	}
+
+	if !IsSupportedCurrency(currency) {
+		return Money{}, fmt.Errorf("currency %q: %w", currency, common.ErrUnsupportedCurrency)
+	}
+
+	amount = strings.TrimSpace(amount)
+	whole, fraction, _ := strings.Cut(amount, ".")
+
+	exponent := CurrencyExponent(currency)
+	if len(fraction) > exponent {
+		return Money{}, fmt.Errorf("amount %q: %w", amount, common.ErrNotMatch)
+	}
+
+	minor, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", exponent-len(fraction)), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("amount %q: %w", amount, common.ErrNotMatch)
+	}
+
+	return NewMoney(minor, currency), nil
+}
+
+// IsSupportedCurrency reports whether code is a currency Money can hold.
+func IsSupportedCurrency(code string) bool {
+	_, ok := currencyExponents[code]
+	return ok
+}
+
+// CurrencyExponent returns the number of decimal places of currency.
+func CurrencyExponent(currency string) int {
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		return 2
+	}
+	return exponent
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns m + o. Both values must be in the same currency.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%s + %s: %w", m.Currency, o.Currency, common.ErrCurrencyMismatch)
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+// Sub returns m - o. Both values must be in the same currency.
+func (m Money) Sub(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%s - %s: %w", m.Currency, o.Currency, common.ErrCurrencyMismatch)
+	}
+	return Money{Amount: m.Amount - o.Amount, Currency: m.Currency}, nil
+}
+
+// Mul returns m multiplied by a whole quantity.
+func (m Money) Mul(quantity int64) Money {
+	return Money{Amount: m.Amount * quantity, Currency: m.Currency}
+}
+
+// MulRatio returns m * num / den rounded half away from zero.
+func (m Money) MulRatio(num, den int64) Money {
+	return Money{Amount: divRound(m.Amount*num, den), Currency: m.Currency}
+}
+
+// String formats m in major units, e.g. "15000.00 IDR".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// Decimal formats the amount in major units without the currency code.
+func (m Money) Decimal() string {
+	exponent := CurrencyExponent(m.Currency)
+
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+
+	split := len(digits) - exponent
+	return sign + digits[:split] + "." + digits[split:]
+}
+
+// MarshalJSON implements json.Marshaler
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{
+		Amount:   m.Amount,
+		Currency: m.Currency,
+		Display:  m.Decimal(),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler. A missing currency defaults to
+// BaseCurrency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw moneyJSON
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(raw.Currency))
+	if currency == "" {
+		currency = BaseCurrency
+	}
+
+	if !IsSupportedCurrency(currency) {
+		return fmt.Errorf("currency %q: %w", raw.Currency, common.ErrUnsupportedCurrency)
+	}
+
+	m.Amount = raw.Amount
+	m.Currency = currency
+	return nil
+}
+
+// divRound divides a by b rounding half away from zero.
+func divRound(a, b int64) int64 {
+	if (a < 0) != (b < 0) {
+		return (a - b/2) / b
+	}
+	return (a + b/2) / b
+}
@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// Notification types
+const (
+	NotificationLowStock    = "low_stock"
+	NotificationBackInStock = "back_in_stock"
+)
+
+// DATABASE
+type (
+	Notification struct {
+		Id        int
+		UserId    int `gorm:"index"`
+		Type      string
+		ProductId int
+		Message   string
+		ReadAt    *time.Time
+		CreatedAt time.Time
+	}
+
+	StockSubscription struct {
+		Id         int
+		ProductId  int `gorm:"index"`
+		UserId     int
+		NotifiedAt *time.Time
+		CreatedAt  time.Time
+	}
+)
+
+// RESPONSE
+type (
+	NotificationRes struct {
+		Id        int        `json:"id"`
+		Type      string     `json:"type"`
+		ProductId int        `json:"product_id"`
+		Message   string     `json:"message"`
+		ReadAt    *time.Time `json:"read_at"`
+		CreatedAt time.Time  `json:"created_at"`
+	}
+)
+
+// Formatter Response
+func NotificationFormatRes(notification Notification) NotificationRes {
+	return NotificationRes{
+		Id:        notification.Id,
+		Type:      notification.Type,
+		ProductId: notification.ProductId,
+		Message:   notification.Message,
+		ReadAt:    notification.ReadAt,
+		CreatedAt: notification.CreatedAt,
+	}
+}
+
+func NotificationsFormatRes(notifications []Notification) []NotificationRes {
+	notificationsFormatRes := []NotificationRes{}
+
+	for _, notification := range notifications {
+		notificationsFormatRes = append(notificationsFormatRes, NotificationFormatRes(notification))
+	}
+
+	return notificationsFormatRes
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Import job statuses
+const (
+	ImportJobPending   = "pending"
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+)
+
+// ProductFileColumns is the column order of catalog exports. Imports match
+// columns by header name, so they may come in any order.
+var ProductFileColumns = []string{
+	"sku", "barcode", "name", "description", "quantity", "price", "currency",
+	"low_stock_threshold", "tax_class_id", "tax_inclusive",
+	"slug", "meta_title", "meta_description",
+}
+
+// DATABASE
+type ProductImportJob struct {
+	Id          int
+	UserId      int
+	FileName    string
+	DryRun      bool
+	Status      string
+	TotalRows   int
+	CreatedRows int
+	UpdatedRows int
+	FailedRows  int
+	Errors      string `gorm:"type:text"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	FinishedAt  *time.Time
+}
+
+// ImportRowError is a problem with one row of an import file. Row counts
+// the header as row 1, like a spreadsheet does.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Sku     string `json:"sku"`
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// RESPONSE
+type (
+	ProductImportJobRes struct {
+		Id          int              `json:"id"`
+		FileName    string           `json:"file_name"`
+		DryRun      bool             `json:"dry_run"`
+		Status      string           `json:"status"`
+		TotalRows   int              `json:"total_rows"`
+		CreatedRows int              `json:"created_rows"`
+		UpdatedRows int              `json:"updated_rows"`
+		FailedRows  int              `json:"failed_rows"`
+		Errors      []ImportRowError `json:"errors"`
+		CreatedAt   time.Time        `json:"created_at"`
+		FinishedAt  *time.Time       `json:"finished_at"`
+	}
+)
+
+// Formatter Response
+func ProductImportJobFormatRes(job ProductImportJob) ProductImportJobRes {
+	rowErrors := []ImportRowError{}
+	if job.Errors != "" {
+		_ = json.Unmarshal([]byte(job.Errors), &rowErrors)
+	}
+
+	return ProductImportJobRes{
+		Id:          job.Id,
+		FileName:    job.FileName,
+		DryRun:      job.DryRun,
+		Status:      job.Status,
+		TotalRows:   job.TotalRows,
+		CreatedRows: job.CreatedRows,
+		UpdatedRows: job.UpdatedRows,
+		FailedRows:  job.FailedRows,
+		Errors:      rowErrors,
+		CreatedAt:   job.CreatedAt,
+		FinishedAt:  job.FinishedAt,
+	}
+}
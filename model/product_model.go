@@ -1,75 +1,180 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // DATABASE
 type (
 	Product struct {
-		Id            int
-		Name          string
-		Description   string
-		Quantity      int
-		Price         int
-		ProductImages []ProductImage
-		CreatedAt     time.Time
-		UpdatedAt     time.Time
-		DeletedAt     time.Time
+		Id                int
+		Sku               string `gorm:"index:idx_products_sku,unique,where:sku <> ''"`
+		Barcode           string `gorm:"index:idx_products_barcode,unique,where:barcode <> ''"`
+		Name              string
+		Slug              string `gorm:"uniqueIndex"`
+		MetaTitle         string
+		MetaDescription   string
+		Description       string
+		Quantity          int
+		LowStockThreshold int
+		LowStockAlertedAt *time.Time
+		Price             Money `gorm:"embedded;embeddedPrefix:price_"`
+		TaxClassId        *int
+		TaxInclusive      bool
+		TaxClass          TaxClass
+		ProductImages     []ProductImage
+		WarehouseStocks   []WarehouseStock
+		CreatedAt         time.Time
+		UpdatedAt         time.Time
+		DeletedAt         gorm.DeletedAt `gorm:"index"`
 	}
 
 	ProductImage struct {
-		Id        int
-		ProductId int
-		FileName  string
-		IsPrimary string
-		CreatedAt time.Time
-		UpdatedAt time.Time
+		Id          int
+		ProductId   int
+		FileName    string
+		ContentHash string `gorm:"index;size:64"`
+		IsPrimary   string
+		Position    int
+		AltText     string
+		CreatedAt   time.Time
+		UpdatedAt   time.Time
+		DeletedAt   gorm.DeletedAt `gorm:"index"`
 	}
 )
 
 // REQUEST
 type (
 	ProductReq struct {
-		Name        string `json:"name" validate:"required"`
-		Description string `json:"description" validate:"required"`
-		Quantity    int    `json:"quantity" validate:"required"`
-		Price       int    `json:"price" validate:"required"`
+		Sku               string `json:"sku" validate:"omitempty,max=64"`
+		Barcode           string `json:"barcode" validate:"omitempty,barcode"`
+		Name              string `json:"name" validate:"required"`
+		Description       string `json:"description" validate:"required"`
+		Slug              string `json:"slug" validate:"omitempty,max=200,slug"`
+		MetaTitle         string `json:"meta_title" validate:"omitempty,max=70"`
+		MetaDescription   string `json:"meta_description" validate:"omitempty,max=160"`
+		Quantity          int    `json:"quantity" validate:"required"`
+		LowStockThreshold int    `json:"low_stock_threshold" validate:"gte=0"`
+		Price             Money  `json:"price" validate:"required"`
+		TaxClassId        int    `json:"tax_class_id"`
+		TaxInclusive      bool   `json:"tax_inclusive"`
 	}
 
 	ProductImagesUploadReq struct {
 		IsPrimary string `form:"is_primary"`
+		AltText   string `form:"alt_text" validate:"max=255"`
+	}
+
+	ProductImageImportReq struct {
+		Url       string `json:"url" validate:"required,url"`
+		IsPrimary string `json:"is_primary" validate:"omitempty,oneof=yes no"`
+		AltText   string `json:"alt_text" validate:"max=255"`
+	}
+
+	ProductImageReq struct {
+		AltText string `json:"alt_text" validate:"max=255"`
+	}
+
+	ProductImageOrderReq struct {
+		ImageIds []int `json:"image_ids" validate:"required,min=1,unique"`
 	}
 )
 
 // RESPONSE
 type (
 	ProductImageRes struct {
+		Id        int    `json:"id"`
 		ProductId int    `json:"product_id"`
 		FileName  string `json:"file_name"`
 		IsPrimary string `json:"is_primary"`
+		Position  int    `json:"position"`
+		AltText   string `json:"alt_text"`
 	}
 
 	ProductRes struct {
-		Name          string            `json:"name"`
-		Description   string            `json:"description"`
-		Quantity      int               `json:"quantity"`
-		Price         int               `json:"price"`
-		ProductImages []ProductImageRes `json:"product_images"`
+		Id                int               `json:"id"`
+		Sku               string            `json:"sku"`
+		Barcode           string            `json:"barcode"`
+		Name              string            `json:"name"`
+		Slug              string            `json:"slug"`
+		MetaTitle         string            `json:"meta_title"`
+		MetaDescription   string            `json:"meta_description"`
+		Description       string            `json:"description"`
+		Quantity          int               `json:"quantity"`
+		LowStockThreshold int               `json:"low_stock_threshold"`
+		Price             Money             `json:"price"`
+		DisplayPrice      *DisplayPriceRes  `json:"display_price,omitempty"`
+		Tax               TaxRes            `json:"tax"`
+		Availability      AvailabilityRes   `json:"availability"`
+		ProductImages     []ProductImageRes `json:"product_images"`
+		DeletedAt         *time.Time        `json:"deleted_at,omitempty"`
+	}
+
+	ProductDetailRes struct {
+		ProductRes
+		RelatedProducts []ProductRes `json:"related_products"`
 	}
 
 	ProductImagesRes struct {
 		ProductImages []ProductImageRes `json:"product_images"`
 	}
+
+	ProductImageUploadResult struct {
+		FileName string           `json:"file_name"`
+		Uploaded bool             `json:"uploaded"`
+		Image    *ProductImageRes `json:"image,omitempty"`
+		Error    string           `json:"error,omitempty"`
+	}
+
+	ProductImagesUploadRes struct {
+		Message string                     `json:"message"`
+		Results []ProductImageUploadResult `json:"results"`
+	}
 )
 
+// SetTaxClass makes taxClass the tax class of p. The zero TaxClass leaves p
+// without one, stored as a NULL tax_class_id.
+func (p *Product) SetTaxClass(taxClass TaxClass) {
+	p.TaxClass = taxClass
+	p.TaxClassId = nil
+	if taxClass.Id != 0 {
+		p.TaxClassId = &taxClass.Id
+	}
+}
+
 // Formatter Response
 func ProductFormatRes(product Product) ProductRes {
 	response := ProductRes{
-		Name:          product.Name,
-		Description:   product.Description,
-		Quantity:      product.Quantity,
-		Price:         product.Price,
-		ProductImages: ProductImagesFormatRes(product.ProductImages),
+		Id:                product.Id,
+		Sku:               product.Sku,
+		Barcode:           product.Barcode,
+		Name:              product.Name,
+		Slug:              product.Slug,
+		MetaTitle:         product.MetaTitle,
+		MetaDescription:   product.MetaDescription,
+		Description:       product.Description,
+		Quantity:          product.Quantity,
+		LowStockThreshold: product.LowStockThreshold,
+		Price:             product.Price,
+		Tax:               TaxFormatRes(product.Price, product.TaxClass, product.TaxInclusive),
+		Availability:      AvailabilityFormatRes(product),
+		ProductImages:     ProductImagesFormatRes(product.ProductImages),
+	}
+
+	if response.MetaTitle == "" {
+		response.MetaTitle = product.Name
+	}
+
+	if response.MetaDescription == "" {
+		response.MetaDescription = truncate(product.Description, 160)
+	}
+
+	if product.DeletedAt.Valid {
+		response.DeletedAt = &product.DeletedAt.Time
 	}
+
 	return response
 }
 
@@ -87,9 +192,12 @@ func ProductsFormatRes(products []Product) []ProductRes {
 
 func ProductImageFormatRes(pi ProductImage) ProductImageRes {
 	return ProductImageRes{
+		Id:        pi.Id,
 		ProductId: pi.ProductId,
 		FileName:  pi.FileName,
 		IsPrimary: pi.IsPrimary,
+		Position:  pi.Position,
+		AltText:   pi.AltText,
 	}
 }
 
@@ -103,3 +211,12 @@ func ProductImagesFormatRes(images []ProductImage) []ProductImageRes {
 	}
 	return productImagesFormatRes
 }
+
+// truncate cuts s to at most n runes.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
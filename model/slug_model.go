@@ -0,0 +1,101 @@
+package model
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// DATABASE
+type ProductSlug struct {
+	Id        int
+	ProductId int    `gorm:"index"`
+	Slug      string `gorm:"uniqueIndex"`
+	CreatedAt time.Time
+}
+
+// Slugify turns s into a lowercase, dash separated URL segment. Accents are
+// dropped and a slug made only of digits is prefixed so it never collides
+// with a numeric product id.
+func Slugify(s string) string {
+	slug := slugify(s)
+	if slug == "" {
+		return "product"
+	}
+
+	if numericSlug(slug) {
+		return "product-" + slug
+	}
+
+	return slug
+}
+
+// ValidSlug reports whether s has a letter to make a slug of, so Slugify
+// doesn't have to make one up or prefix it.
+func ValidSlug(s string) bool {
+	slug := slugify(s)
+	return slug != "" && !numericSlug(slug)
+}
+
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+			dash = false
+		case b.Len() > 0 && !dash:
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// numericSlug reports whether slug is made only of digits. Such a slug
+// can't be served, since /products/{id} claims every numeric segment.
+func numericSlug(slug string) bool {
+	return strings.Trim(slug, "0123456789") == ""
+}
+
+// RESPONSE
+type (
+	SitemapRes struct {
+		XMLName xml.Name     `xml:"urlset"`
+		Xmlns   string       `xml:"xmlns,attr"`
+		URLs    []SitemapURL `xml:"url"`
+	}
+
+	SitemapURL struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod,omitempty"`
+	}
+)
+
+// Formatter Response
+func SitemapFormatRes(baseURL string, products []Product) SitemapRes {
+	response := SitemapRes{
+		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
+		URLs:  []SitemapURL{},
+	}
+
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	for _, product := range products {
+		sitemapURL := SitemapURL{
+			Loc:     baseURL + "/products/" + product.Slug,
+			LastMod: product.UpdatedAt.Format("2006-01-02"),
+		}
+
+		response.URLs = append(response.URLs, sitemapURL)
+	}
+
+	return response
+}
@@ -0,0 +1,90 @@
+package model
+
+import "time"
+
+// DATABASE
+type TaxClass struct {
+	Id        int
+	Code      string `gorm:"uniqueIndex"`
+	Name      string
+	Rate      int // basis points, 1100 = 11%
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// REQUEST
+type (
+	TaxClassReq struct {
+		Code string `json:"code" validate:"required"`
+		Name string `json:"name" validate:"required"`
+		Rate int    `json:"rate" validate:"gte=0,lte=10000"`
+	}
+)
+
+// RESPONSE
+type (
+	TaxClassRes struct {
+		Id   int     `json:"id"`
+		Code string  `json:"code"`
+		Name string  `json:"name"`
+		Rate float64 `json:"rate"`
+	}
+
+	TaxRes struct {
+		TaxClass   string  `json:"tax_class"`
+		Rate       float64 `json:"rate"`
+		Inclusive  bool    `json:"inclusive"`
+		NetPrice   Money   `json:"net_price"`
+		TaxAmount  Money   `json:"tax_amount"`
+		GrossPrice Money   `json:"gross_price"`
+	}
+)
+
+// CalculateTax splits price into its net and tax parts. rate is in basis
+// points; inclusive tells whether price already contains the tax.
+func CalculateTax(price Money, rate int, inclusive bool) (net Money, tax Money, gross Money) {
+	if rate <= 0 {
+		return price, NewMoney(0, price.Currency), price
+	}
+
+	if inclusive {
+		net = price.MulRatio(10000, int64(10000+rate))
+		return net, NewMoney(price.Amount-net.Amount, price.Currency), price
+	}
+
+	tax = price.MulRatio(int64(rate), 10000)
+	return price, tax, NewMoney(price.Amount+tax.Amount, price.Currency)
+}
+
+// Formatter Response
+func TaxClassFormatRes(taxClass TaxClass) TaxClassRes {
+	return TaxClassRes{
+		Id:   taxClass.Id,
+		Code: taxClass.Code,
+		Name: taxClass.Name,
+		Rate: float64(taxClass.Rate) / 100,
+	}
+}
+
+func TaxClassesFormatRes(taxClasses []TaxClass) []TaxClassRes {
+	taxClassesFormatRes := []TaxClassRes{}
+
+	for _, taxClass := range taxClasses {
+		taxClassesFormatRes = append(taxClassesFormatRes, TaxClassFormatRes(taxClass))
+	}
+
+	return taxClassesFormatRes
+}
+
+func TaxFormatRes(price Money, taxClass TaxClass, inclusive bool) TaxRes {
+	net, tax, gross := CalculateTax(price, taxClass.Rate, inclusive)
+
+	return TaxRes{
+		TaxClass:   taxClass.Code,
+		Rate:       float64(taxClass.Rate) / 100,
+		Inclusive:  inclusive,
+		NetPrice:   net,
+		TaxAmount:  tax,
+		GrossPrice: gross,
+	}
+}
@@ -7,6 +7,7 @@ type User struct {
 	Id        int
 	Username  string
 	Email     string
+	Phone     string
 	Password  string
 	Role      string
 	CreatedAt time.Time
@@ -18,7 +19,8 @@ type (
 	RegisterReq struct {
 		Username string `json:"username" validate:"required"`
 		Email    string `json:"email" validate:"required,email"`
-		Password string `json:"password" validate:"required"`
+		Phone    string `json:"phone" validate:"omitempty,phone"`
+		Password string `json:"password" validate:"required,strong_password"`
 	}
 
 	LoginReq struct {
@@ -28,14 +30,14 @@ type (
 
 	ChangePassReq struct {
 		Password        string `json:"password" validate:"required"`
-		NewPassword     string `json:"new_password" validate:"required"`
+		NewPassword     string `json:"new_password" validate:"required,strong_password"`
 		ConfirmPassword string `json:"confirm_password" validate:"required"`
 	}
 
 	RegisterAdminReq struct {
 		Username  string `json:"username" validate:"required"`
 		Email     string `json:"email" validate:"required,email"`
-		Password  string `json:"password" validate:"required"`
+		Password  string `json:"password" validate:"required,strong_password"`
 		CodeAdmin int    `json:"code_admin" validate:"required"`
 	}
 )
@@ -43,6 +45,7 @@ type (
 // RESPONSE
 type (
 	RegisterRes struct {
+		Id       int    `json:"id"`
 		Username string `json:"username"`
 	}
 
@@ -53,6 +56,7 @@ type (
 	ProfileRes struct {
 		Username string `json:"username"`
 		Email    string `json:"email"`
+		Phone    string `json:"phone"`
 		Role     string `json:"role"`
 	}
 
@@ -61,6 +65,7 @@ type (
 	}
 
 	RegisterAdminRes struct {
+		Id       int    `json:"id"`
 		Username string `json:"username"`
 	}
 )
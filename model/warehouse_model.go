@@ -0,0 +1,197 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// Stock statuses
+const (
+	StockStatusInStock    = "in_stock"
+	StockStatusLowStock   = "low_stock"
+	StockStatusOutOfStock = "out_of_stock"
+)
+
+// DATABASE
+type (
+	Warehouse struct {
+		Id        int
+		Code      string `gorm:"uniqueIndex"`
+		Name      string
+		Address   string
+		Latitude  *float64
+		Longitude *float64
+		CreatedAt time.Time
+		UpdatedAt time.Time
+	}
+
+	WarehouseStock struct {
+		Id          int
+		WarehouseId int `gorm:"uniqueIndex:idx_warehouse_stock"`
+		ProductId   int `gorm:"uniqueIndex:idx_warehouse_stock"`
+		Quantity    int
+		Warehouse   Warehouse
+		UpdatedAt   time.Time
+	}
+
+	StockTransfer struct {
+		Id              int
+		ProductId       int `gorm:"index"`
+		FromWarehouseId int
+		ToWarehouseId   int
+		Quantity        int
+		UserId          int
+		CreatedAt       time.Time
+	}
+)
+
+// REQUEST
+type (
+	WarehouseReq struct {
+		Code      string   `json:"code" validate:"required"`
+		Name      string   `json:"name" validate:"required"`
+		Address   string   `json:"address" validate:"required"`
+		Latitude  *float64 `json:"latitude" validate:"omitempty,latitude"`
+		Longitude *float64 `json:"longitude" validate:"omitempty,longitude"`
+	}
+
+	StockTransferReq struct {
+		ProductId       int `json:"product_id" validate:"required"`
+		FromWarehouseId int `json:"from_warehouse_id" validate:"required"`
+		ToWarehouseId   int `json:"to_warehouse_id" validate:"required,nefield=FromWarehouseId"`
+		Quantity        int `json:"quantity" validate:"required,gt=0"`
+	}
+
+	StockAllocationReq struct {
+		ProductId int `json:"product_id" validate:"required"`
+		Quantity  int `json:"quantity" validate:"required,gt=0"`
+		AddressId int `json:"address_id"`
+	}
+)
+
+// RESPONSE
+type (
+	WarehouseRes struct {
+		Id        int      `json:"id"`
+		Code      string   `json:"code"`
+		Name      string   `json:"name"`
+		Address   string   `json:"address"`
+		Latitude  *float64 `json:"latitude"`
+		Longitude *float64 `json:"longitude"`
+	}
+
+	WarehouseStockRes struct {
+		WarehouseId int `json:"warehouse_id"`
+		ProductId   int `json:"product_id"`
+		Quantity    int `json:"quantity"`
+	}
+
+	StockTransferRes struct {
+		Id              int       `json:"id"`
+		ProductId       int       `json:"product_id"`
+		FromWarehouseId int       `json:"from_warehouse_id"`
+		ToWarehouseId   int       `json:"to_warehouse_id"`
+		Quantity        int       `json:"quantity"`
+		CreatedAt       time.Time `json:"created_at"`
+	}
+
+	StockAllocationRes struct {
+		Warehouse  WarehouseRes         `json:"warehouse"`
+		Available  int                  `json:"available"`
+		DistanceKm *float64             `json:"distance_km"`
+		Movement   InventoryMovementRes `json:"movement"`
+	}
+
+	AvailabilityRes struct {
+		Status     string `json:"status"`
+		InStock    bool   `json:"in_stock"`
+		Quantity   int    `json:"quantity"`
+		Warehouses int    `json:"warehouses"`
+	}
+)
+
+// DistanceKm returns the great-circle distance between two points.
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371.0
+
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLon := (lon2 - lon1) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// Formatter Response
+func WarehouseFormatRes(warehouse Warehouse) WarehouseRes {
+	return WarehouseRes{
+		Id:        warehouse.Id,
+		Code:      warehouse.Code,
+		Name:      warehouse.Name,
+		Address:   warehouse.Address,
+		Latitude:  warehouse.Latitude,
+		Longitude: warehouse.Longitude,
+	}
+}
+
+func WarehousesFormatRes(warehouses []Warehouse) []WarehouseRes {
+	warehousesFormatRes := []WarehouseRes{}
+
+	for _, warehouse := range warehouses {
+		warehousesFormatRes = append(warehousesFormatRes, WarehouseFormatRes(warehouse))
+	}
+
+	return warehousesFormatRes
+}
+
+func WarehouseStocksFormatRes(stocks []WarehouseStock) []WarehouseStockRes {
+	warehouseStocksFormatRes := []WarehouseStockRes{}
+
+	for _, stock := range stocks {
+		warehouseStockFormatRes := WarehouseStockRes{
+			WarehouseId: stock.WarehouseId,
+			ProductId:   stock.ProductId,
+			Quantity:    stock.Quantity,
+		}
+
+		warehouseStocksFormatRes = append(warehouseStocksFormatRes, warehouseStockFormatRes)
+	}
+
+	return warehouseStocksFormatRes
+}
+
+func StockTransferFormatRes(transfer StockTransfer) StockTransferRes {
+	return StockTransferRes{
+		Id:              transfer.Id,
+		ProductId:       transfer.ProductId,
+		FromWarehouseId: transfer.FromWarehouseId,
+		ToWarehouseId:   transfer.ToWarehouseId,
+		Quantity:        transfer.Quantity,
+		CreatedAt:       transfer.CreatedAt,
+	}
+}
+
+// AvailabilityFormatRes aggregates stock over all warehouses. Stock not yet
+// assigned to a warehouse still counts towards the total.
+func AvailabilityFormatRes(product Product) AvailabilityRes {
+	warehouses := 0
+	for _, stock := range product.WarehouseStocks {
+		if stock.Quantity > 0 {
+			warehouses++
+		}
+	}
+
+	status := StockStatusInStock
+	if product.Quantity <= 0 {
+		status = StockStatusOutOfStock
+	} else if product.Quantity <= product.LowStockThreshold {
+		status = StockStatusLowStock
+	}
+
+	return AvailabilityRes{
+		Status:     status,
+		InStock:    product.Quantity > 0,
+		Quantity:   product.Quantity,
+		Warehouses: warehouses,
+	}
+}
@@ -1,7 +1,7 @@
 package repository
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"learn/common"
 	"learn/model"
@@ -10,12 +10,12 @@ import (
 )
 
 type AddressRepository interface {
-	Create(address model.Address) (model.Address, error)
-	MarkAllAddressNonPrimary(addresId int) (bool, error)
-	FindByUserId(userId int) ([]model.Address, error)
-	FindByAddressId(addressId int) (model.Address, error)
-	Update(address model.Address) (model.Address, error)
-	Delete(addressId int) error
+	Create(ctx context.Context, address model.Address) (model.Address, error)
+	MarkAllAddressNonPrimary(ctx context.Context, addresId int) (bool, error)
+	FindByUserId(ctx context.Context, userId int) ([]model.Address, error)
+	FindByAddressId(ctx context.Context, addressId int) (model.Address, error)
+	Update(ctx context.Context, address model.Address) (model.Address, error)
+	Delete(ctx context.Context, addressId int) error
 }
 
 type addressRepository struct {
@@ -34,74 +34,67 @@ var (
 )
 
 // Create implements AddressRepository
-func (r *addressRepository) Create(address model.Address) (model.Address, error) {
-	err := r.DB.Create(&address).Error
+func (r *addressRepository) Create(ctx context.Context, address model.Address) (model.Address, error) {
+	err := r.DB.WithContext(ctx).Create(&address).Error
 	if err != nil {
-		if errors.Is(err, common.ErrFailedCreateData) {
-			return emptyAddress, fmt.Errorf("address: %w", common.ErrFailedCreateData)
-		}
+		return emptyAddress, fmt.Errorf("address user %d: %w", address.UserId, translateError(err))
 	}
 
 	return address, nil
 }
 
 // MarkAllAddressNonPrimary implements AddressRepository
-func (r *addressRepository) MarkAllAddressNonPrimary(UserId int) (bool, error) {
-	err := r.DB.Model(&model.Address{}).Where("user_id = ?", UserId).Update("is_primary", "no").Error
+func (r *addressRepository) MarkAllAddressNonPrimary(ctx context.Context, UserId int) (bool, error) {
+	err := r.DB.WithContext(ctx).Model(&model.Address{}).Where("user_id = ?", UserId).Update("is_primary", "no").Error
 	if err != nil {
-		if errors.Is(err, common.ErrMustHavePrimary) {
-			return false, fmt.Errorf("address primary: %w", common.ErrMustHavePrimary)
-		}
+		return false, fmt.Errorf("address user %d: %w", UserId, err)
 	}
 
 	return true, nil
 }
 
 // GetAllAddaress implements AddressRepository
-func (r *addressRepository) FindByUserId(userId int) ([]model.Address, error) {
+func (r *addressRepository) FindByUserId(ctx context.Context, userId int) ([]model.Address, error) {
 	addresses := []model.Address{}
-	err := r.DB.Where("user_id = ?", userId).Find(&addresses).Error
+	err := r.DB.WithContext(ctx).Where("user_id = ?", userId).Find(&addresses).Error
 	if err != nil {
-		if errors.Is(err, common.ErrNotFound) {
-			return emptyAddresses, fmt.Errorf("address user id %d: %w", userId, common.ErrNotFound)
-		}
+		return emptyAddresses, fmt.Errorf("address user %d: %w", userId, err)
 	}
 
 	return addresses, nil
 }
 
 // FindByAddressId implements AddressRepository
-func (r *addressRepository) FindByAddressId(addressId int) (model.Address, error) {
+func (r *addressRepository) FindByAddressId(ctx context.Context, addressId int) (model.Address, error) {
 	address := model.Address{}
-	err := r.DB.Where("id = ?", addressId).Find(&address).Error
+	err := r.DB.WithContext(ctx).Where("id = ?", addressId).First(&address).Error
 	if err != nil {
-		if errors.Is(err, common.ErrNotFound) {
-			return emptyAddress, fmt.Errorf("address id %d: %w", addressId, common.ErrNotFound)
-		}
+		return emptyAddress, fmt.Errorf("address %d: %w", addressId, translateError(err))
 	}
 
 	return address, nil
 }
 
-// Create implements AddressRepository
-func (r *addressRepository) Update(address model.Address) (model.Address, error) {
-	err := r.DB.Save(&address).Error
+// Update implements AddressRepository
+func (r *addressRepository) Update(ctx context.Context, address model.Address) (model.Address, error) {
+	err := r.DB.WithContext(ctx).Save(&address).Error
 	if err != nil {
-		if errors.Is(err, common.ErrFailedUpdateData) {
-			return address, fmt.Errorf("address : %w", common.ErrFailedUpdateData)
-		}
+		return emptyAddress, fmt.Errorf("address %d: %w", address.Id, translateError(err))
 	}
 
 	return address, nil
 }
 
-// Delete implements AddressRepository
-func (r *addressRepository) Delete(addressId int) error {
-	err := r.DB.Delete(&model.Address{}, addressId).Error
-	if err != nil {
-		if errors.Is(err, common.ErrNotFound) {
-			return fmt.Errorf("address %d: %w", addressId, common.ErrNotFound)
-		}
+// Delete implements AddressRepository. Deleting an address that does not
+// exist is ErrNotFound.
+func (r *addressRepository) Delete(ctx context.Context, addressId int) error {
+	result := r.DB.WithContext(ctx).Delete(&model.Address{}, addressId)
+	if result.Error != nil {
+		return fmt.Errorf("address %d: %w", addressId, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("address %d: %w", addressId, common.ErrNotFound)
 	}
 
 	return nil
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"fmt"
+	"learn/model"
+
+	"gorm.io/gorm"
+)
+
+type AuditRepository interface {
+	CreateLog(log model.AuditLog) (model.AuditLog, error)
+	FindLogs(filter model.AuditLogFilter) ([]model.AuditLog, error)
+}
+
+type auditRepository struct {
+	DB *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{
+		DB: db,
+	}
+}
+
+var (
+	emptyAuditLog  = model.AuditLog{}
+	emptyAuditLogs = []model.AuditLog{}
+)
+
+// CreateLog implements AuditRepository
+func (r *auditRepository) CreateLog(log model.AuditLog) (model.AuditLog, error) {
+	err := r.DB.Create(&log).Error
+	if err != nil {
+		return emptyAuditLog, fmt.Errorf("audit log: %w", err)
+	}
+
+	return log, nil
+}
+
+// FindLogs implements AuditRepository. Zero filter fields match anything;
+// logs come newest first.
+func (r *auditRepository) FindLogs(filter model.AuditLogFilter) ([]model.AuditLog, error) {
+	logs := []model.AuditLog{}
+
+	query := r.DB.Model(&model.AuditLog{})
+	if filter.UserId != 0 {
+		query = query.Where("user_id = ?", filter.UserId)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityId != 0 {
+		query = query.Where("entity_id = ?", filter.EntityId)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	err := query.Order("created_at desc").Order("id desc").Limit(filter.Limit).Offset(filter.Offset).Find(&logs).Error
+	if err != nil {
+		return emptyAuditLogs, fmt.Errorf("audit log: %w", err)
+	}
+
+	return logs, nil
+}
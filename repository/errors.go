@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"learn/common"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// pgUniqueViolation is the Postgres error code for a unique violation.
+const pgUniqueViolation = "23505"
+
+// translateError maps database errors onto domain errors: a missing row is
+// ErrNotFound and a unique violation ErrExists. The database error stays in
+// the chain for logs. Other errors are returned unchanged.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %w", common.ErrNotFound, err)
+	}
+
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return fmt.Errorf("%w: %w", common.ErrExists, err)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return fmt.Errorf("%w: %s: %w", common.ErrExists, pgErr.ConstraintName, err)
+	}
+
+	return err
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"fmt"
+	"learn/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ExchangeRateRepository interface {
+	SaveRates(rates []model.ExchangeRate) ([]model.ExchangeRate, error)
+	FindAllRates() ([]model.ExchangeRate, error)
+	FindRateByCurrency(currency string) (model.ExchangeRate, error)
+}
+
+type exchangeRateRepository struct {
+	DB *gorm.DB
+}
+
+func NewExchangeRateRepository(db *gorm.DB) ExchangeRateRepository {
+	return &exchangeRateRepository{
+		DB: db,
+	}
+}
+
+var (
+	emptyExchangeRate  = model.ExchangeRate{}
+	emptyExchangeRates = []model.ExchangeRate{}
+)
+
+// SaveRates implements ExchangeRateRepository. Rates are upserted by
+// currency in a single statement so an import is applied all or nothing.
+func (r *exchangeRateRepository) SaveRates(rates []model.ExchangeRate) ([]model.ExchangeRate, error) {
+	err := r.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "currency"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "updated_at"}),
+	}).Create(&rates).Error
+	if err != nil {
+		return emptyExchangeRates, fmt.Errorf("exchange rate: %w", err)
+	}
+
+	return rates, nil
+}
+
+// FindAllRates implements ExchangeRateRepository
+func (r *exchangeRateRepository) FindAllRates() ([]model.ExchangeRate, error) {
+	rates := []model.ExchangeRate{}
+
+	err := r.DB.Order("currency").Find(&rates).Error
+	if err != nil {
+		return emptyExchangeRates, fmt.Errorf("exchange rate: %w", err)
+	}
+
+	return rates, nil
+}
+
+// FindRateByCurrency implements ExchangeRateRepository
+func (r *exchangeRateRepository) FindRateByCurrency(currency string) (model.ExchangeRate, error) {
+	rate := model.ExchangeRate{}
+
+	err := r.DB.Where("currency = ?", currency).Find(&rate).Error
+	if err != nil {
+		return emptyExchangeRate, fmt.Errorf("exchange rate %s: %w", currency, err)
+	}
+
+	return rate, nil
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"fmt"
+	"learn/common"
+	"learn/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InventoryRepository interface {
+	RecordMovement(movement model.InventoryMovement) (model.InventoryMovement, error)
+	FindMovementsByProductId(productId int) ([]model.InventoryMovement, error)
+	FindStockDrift() ([]model.StockDrift, error)
+}
+
+type inventoryRepository struct {
+	DB *gorm.DB
+}
+
+func NewInventoryRepository(db *gorm.DB) InventoryRepository {
+	return &inventoryRepository{
+		DB: db,
+	}
+}
+
+var (
+	emptyInventoryMovement  = model.InventoryMovement{}
+	emptyInventoryMovements = []model.InventoryMovement{}
+	emptyStockDrifts        = []model.StockDrift{}
+)
+
+// RecordMovement implements InventoryRepository
+func (r *inventoryRepository) RecordMovement(movement model.InventoryMovement) (model.InventoryMovement, error) {
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		movement, err = bookMovement(tx, movement)
+		return err
+	})
+	if err != nil {
+		return emptyInventoryMovement, fmt.Errorf("inventory movement: %w", translateError(err))
+	}
+
+	return movement, nil
+}
+
+// FindMovementsByProductId implements InventoryRepository
+func (r *inventoryRepository) FindMovementsByProductId(productId int) ([]model.InventoryMovement, error) {
+	movements := []model.InventoryMovement{}
+
+	err := r.DB.Where("product_id = ?", productId).Order("id desc").Find(&movements).Error
+	if err != nil {
+		return emptyInventoryMovements, fmt.Errorf("inventory movement product %d: %w", productId, err)
+	}
+
+	return movements, nil
+}
+
+// FindStockDrift implements InventoryRepository
+func (r *inventoryRepository) FindStockDrift() ([]model.StockDrift, error) {
+	drifts := []model.StockDrift{}
+
+	err := r.DB.Table("products").
+		Select("products.id AS product_id, products.name, products.quantity, COALESCE(SUM(inventory_movements.quantity), 0) AS ledger_quantity").
+		Joins("LEFT JOIN inventory_movements ON inventory_movements.product_id = products.id").
+		Where("products.deleted_at IS NULL").
+		Group("products.id").
+		Having("products.quantity <> COALESCE(SUM(inventory_movements.quantity), 0)").
+		Order("products.id").
+		Scan(&drifts).Error
+	if err != nil {
+		return emptyStockDrifts, fmt.Errorf("stock drift: %w", translateError(err))
+	}
+
+	return drifts, nil
+}
+
+// bookMovement appends movement to the ledger inside tx. The product row is
+// locked while its quantity is updated, so the ledger and the stored
+// quantity move together. A movement for a warehouse also changes that
+// location's stock; one without a warehouse can only take stock that is
+// not held by a warehouse, so the warehouses never hold more than the
+// product's quantity.
+func bookMovement(tx *gorm.DB, movement model.InventoryMovement) (model.InventoryMovement, error) {
+	product, err := lockProduct(tx, movement.ProductId)
+	if err != nil {
+		return emptyInventoryMovement, err
+	}
+
+	balance := product.Quantity + movement.Quantity
+	if balance < 0 {
+		return emptyInventoryMovement, fmt.Errorf("product %d: %w", movement.ProductId, common.ErrInsufficientStock)
+	}
+
+	if movement.WarehouseId != 0 {
+		err = adjustWarehouseStock(tx, movement.WarehouseId, movement.ProductId, movement.Quantity)
+		if err != nil {
+			return emptyInventoryMovement, err
+		}
+	} else if movement.Quantity < 0 {
+		var held int
+		err = tx.Model(&model.WarehouseStock{}).
+			Where("product_id = ?", movement.ProductId).
+			Select("COALESCE(SUM(quantity), 0)").
+			Scan(&held).Error
+		if err != nil {
+			return emptyInventoryMovement, err
+		}
+
+		if balance < held {
+			return emptyInventoryMovement, fmt.Errorf("product %d: %d held by warehouses: %w", movement.ProductId, held, common.ErrInsufficientStock)
+		}
+	}
+
+	err = tx.Model(&product).Update("quantity", balance).Error
+	if err != nil {
+		return emptyInventoryMovement, err
+	}
+
+	movement.BalanceAfter = balance
+
+	err = tx.Create(&movement).Error
+	if err != nil {
+		return emptyInventoryMovement, err
+	}
+
+	return movement, nil
+}
+
+// lockProduct locks the row of a live product inside tx. Every change to a
+// product's stock, in total or at a warehouse, takes this lock first.
+func lockProduct(tx *gorm.DB, productId int) (model.Product, error) {
+	product := model.Product{}
+
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", productId).First(&product).Error
+	if err != nil {
+		return emptyProduct, fmt.Errorf("product %d: %w", productId, translateError(err))
+	}
+
+	return product, nil
+}
+
+// adjustWarehouseStock adds quantity to the stock of a product at a
+// warehouse inside tx, refusing to go below zero. The stock row is created
+// on first use; ON CONFLICT DO NOTHING lets a concurrent first use win
+// instead of failing, and the row is then read under lock.
+func adjustWarehouseStock(tx *gorm.DB, warehouseId int, productId int, quantity int) error {
+	warehouse := model.Warehouse{}
+
+	err := tx.Where("id = ?", warehouseId).First(&warehouse).Error
+	if err != nil {
+		return fmt.Errorf("warehouse %d: %w", warehouseId, translateError(err))
+	}
+
+	stock := model.WarehouseStock{WarehouseId: warehouseId, ProductId: productId}
+
+	err = tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "warehouse_id"}, {Name: "product_id"}},
+		DoNothing: true,
+	}).Omit(clause.Associations).Create(&stock).Error
+	if err != nil {
+		return err
+	}
+
+	stock = model.WarehouseStock{}
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("warehouse_id = ? AND product_id = ?", warehouseId, productId).
+		First(&stock).Error
+	if err != nil {
+		return err
+	}
+
+	if stock.Quantity+quantity < 0 {
+		return fmt.Errorf("warehouse %d product %d: %w", warehouseId, productId, common.ErrInsufficientStock)
+	}
+
+	return tx.Model(&stock).Update("quantity", stock.Quantity+quantity).Error
+}
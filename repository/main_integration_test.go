@@ -0,0 +1,99 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"learn/config"
+	"learn/migrations"
+	"log"
+	"os"
+	"testing"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDB is the migrated database the integration tests share. It is
+// DATABASE_URL when set and an embedded Postgres otherwise.
+var testDB *gorm.DB
+
+func TestMain(m *testing.M) {
+	os.Exit(runIntegration(m))
+}
+
+func runIntegration(m *testing.M) int {
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		dir, err := os.MkdirTemp("", "learn-postgres")
+		if err != nil {
+			log.Printf("temp dir: %v", err)
+			return 1
+		}
+		defer os.RemoveAll(dir)
+
+		pg := embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().
+			Port(54329).
+			Database("learn_test").
+			RuntimePath(dir).
+			Logger(nil))
+
+		err = pg.Start()
+		if err != nil {
+			log.Printf("start embedded postgres: %v", err)
+			return 1
+		}
+		defer pg.Stop()
+
+		dsn = "host=localhost port=54329 user=postgres password=postgres dbname=learn_test sslmode=disable"
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		log.Printf("open database: %v", err)
+		return 1
+	}
+
+	migrator, err := config.NewMigrator(db, migrations.Files)
+	if err != nil {
+		log.Printf("load migrations: %v", err)
+		return 1
+	}
+
+	_, err = migrator.Up(context.Background())
+	if err != nil {
+		log.Printf("migrate: %v", err)
+		return 1
+	}
+
+	testDB = db
+	return m.Run()
+}
+
+// newDB returns testDB emptied of the rows earlier tests left behind.
+func newDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	tables, err := testDB.Migrator().GetTables()
+	if err != nil {
+		t.Fatalf("list tables: %v", err)
+	}
+
+	for _, table := range tables {
+		if table == "schema_migrations" {
+			continue
+		}
+
+		err = testDB.Exec(fmt.Sprintf("TRUNCATE TABLE %q RESTART IDENTITY CASCADE", table)).Error
+		if err != nil {
+			t.Fatalf("truncate %s: %v", table, err)
+		}
+	}
+
+	return testDB
+}
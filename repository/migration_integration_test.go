@@ -0,0 +1,232 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"learn/common"
+	"learn/config"
+	"learn/migrations"
+	"learn/model"
+	"learn/repository"
+	"learn/repository/repotest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrationsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	migrator, err := config.NewMigrator(newDB(t), migrations.Files)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+
+	reverted, err := migrator.Down(ctx, len(migrator.Migrations))
+	if err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if len(reverted) != len(migrator.Migrations) {
+		t.Errorf("Down reverted %d migrations, want %d", len(reverted), len(migrator.Migrations))
+	}
+	if testDB.Migrator().HasTable("users") {
+		t.Errorf("users table still exists after reverting every migration")
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(applied) != len(migrator.Migrations) {
+		t.Errorf("Up applied %d migrations, want %d", len(applied), len(migrator.Migrations))
+	}
+
+	pending, err := migrator.Pending(ctx)
+	if err != nil || len(pending) != 0 {
+		t.Errorf("Pending after Up = %d, %v, want none", len(pending), err)
+	}
+}
+
+// legacyMigrator reverts every migration and leaves the tables the first
+// AutoMigrate built, before any of the migrated features existed. The
+// database is migrated again when the test ends.
+func legacyMigrator(t *testing.T) *config.Migrator {
+	t.Helper()
+	ctx := context.Background()
+
+	migrator, err := config.NewMigrator(newDB(t), migrations.Files)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+
+	_, err = migrator.Down(ctx, len(migrator.Migrations))
+	if err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_, err := migrator.Down(ctx, len(migrator.Migrations))
+		if err != nil {
+			t.Errorf("Down: %v", err)
+		}
+
+		err = testDB.Exec("DROP TABLE IF EXISTS product_images, products, addresses, users").Error
+		if err != nil {
+			t.Errorf("drop legacy tables: %v", err)
+		}
+
+		_, err = migrator.Up(ctx)
+		if err != nil {
+			t.Errorf("Up: %v", err)
+		}
+	})
+
+	err = testDB.Exec(`
+		CREATE TABLE users (id bigserial PRIMARY KEY, username text, email text, password text, role text, created_at timestamptz, updated_at timestamptz);
+		CREATE TABLE addresses (id bigserial PRIMARY KEY, address text, is_primary text, user_id bigint, created_at timestamptz, updated_at timestamptz);
+		CREATE TABLE products (id bigserial PRIMARY KEY, name text, description text, quantity bigint, price bigint, created_at timestamptz, updated_at timestamptz, deleted_at timestamptz);
+		CREATE TABLE product_images (id bigserial PRIMARY KEY, product_id bigint, file_name text, is_primary text, created_at timestamptz, updated_at timestamptz);
+	`).Error
+	if err != nil {
+		t.Fatalf("create legacy tables: %v", err)
+	}
+
+	return migrator
+}
+
+func TestMigrationsFromLegacySchema(t *testing.T) {
+	ctx := context.Background()
+	migrator := legacyMigrator(t)
+
+	err := testDB.Exec(`
+		INSERT INTO users (username, email, role) VALUES ('budi', 'budi@example.com', 'user');
+		INSERT INTO addresses (address, is_primary, user_id) VALUES ('Jl. Merdeka 1', 'true', 1);
+		INSERT INTO products (name, description, quantity, price, deleted_at) VALUES ('Kopi Susu', 'Es kopi', 5, 15000, '0001-01-01');
+		INSERT INTO product_images (product_id, file_name, is_primary) VALUES (1, 'a.png', 'true'), (1, 'b.png', 'false');
+	`).Error
+	if err != nil {
+		t.Fatalf("insert legacy rows: %v", err)
+	}
+
+	_, err = migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up from legacy schema: %v", err)
+	}
+
+	var product struct {
+		Slug          string
+		PriceAmount   int64
+		PriceCurrency string
+		DeletedAt     *time.Time
+	}
+	err = testDB.Raw("SELECT slug, price_amount, price_currency, deleted_at FROM products WHERE id = 1").Scan(&product).Error
+	if err != nil {
+		t.Fatalf("select product: %v", err)
+	}
+	if product.Slug != "kopi-susu-1" || product.PriceAmount != 1500000 || product.PriceCurrency != "IDR" || product.DeletedAt != nil {
+		t.Errorf("migrated product = %+v, want slug kopi-susu-1, 1500000 IDR and not deleted", product)
+	}
+
+	var positions []int
+	err = testDB.Raw("SELECT position FROM product_images ORDER BY id").Scan(&positions).Error
+	if err != nil || len(positions) != 2 || positions[0] != 1 || positions[1] != 2 {
+		t.Errorf("image positions = %v, %v, want [1 2]", positions, err)
+	}
+
+	var balances int64
+	err = testDB.Raw("SELECT count(*) FROM inventory_movements WHERE product_id = 1 AND reason = 'opening balance' AND balance_after = 5").Scan(&balances).Error
+	if err != nil || balances != 1 {
+		t.Errorf("opening balances = %d, %v, want 1", balances, err)
+	}
+}
+
+func TestMigrationsReportLegacyProblems(t *testing.T) {
+	migrator := legacyMigrator(t)
+
+	err := testDB.Exec(`
+		INSERT INTO users (username, email, role) VALUES ('budi', 'budi@example.com', 'user'), ('budi2', 'budi@example.com', 'user');
+		INSERT INTO addresses (address, is_primary, user_id) VALUES ('Jl. Merdeka 1', 'true', 99);
+	`).Error
+	if err != nil {
+		t.Fatalf("insert legacy rows: %v", err)
+	}
+
+	_, err = migrator.Up(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "1 addresses with a missing user_id") {
+		t.Errorf("Up with an orphaned address error = %v, want it reported", err)
+	}
+
+	err = testDB.Exec("DELETE FROM addresses").Error
+	if err != nil {
+		t.Fatalf("delete addresses: %v", err)
+	}
+
+	_, err = migrator.Up(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "email budi@example.com (ids 1, 2)") {
+		t.Errorf("Up with a duplicate email error = %v, want it reported", err)
+	}
+
+	var users int64
+	err = testDB.Raw("SELECT count(*) FROM users").Scan(&users).Error
+	if err != nil || users != 2 {
+		t.Errorf("users after failed migration = %d, %v, want both kept", users, err)
+	}
+}
+
+func TestMigrationsConstraints(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	users := repository.NewUserRepository(db)
+	addresses := repository.NewAddressRepository(db)
+
+	user, err := users.CreateUser(ctx, repotest.NewUser())
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	_, err = users.CreateUser(ctx, repotest.NewUser(func(u *model.User) { u.Email = user.Email }))
+	if !errors.Is(err, common.ErrExists) {
+		t.Errorf("CreateUser with taken email error = %v, want ErrExists", err)
+	}
+
+	_, err = addresses.Create(ctx, repotest.NewAddress(user.Id+1))
+	if err == nil {
+		t.Errorf("Create address of missing user succeeded, want a foreign key violation")
+	}
+
+	address, err := addresses.Create(ctx, repotest.NewAddress(user.Id))
+	if err != nil {
+		t.Fatalf("Create address: %v", err)
+	}
+
+	err = db.Exec("DELETE FROM users WHERE id = ?", user.Id).Error
+	if err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+
+	_, err = addresses.FindByAddressId(ctx, address.Id)
+	if !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("address of deleted user error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMigrationsTaxClassConstraints(t *testing.T) {
+	db := newDB(t)
+	taxes := repository.NewTaxRepository(db)
+
+	_, err := taxes.CreateTaxClass(model.TaxClass{Code: "VAT", Name: "VAT", Rate: 1100})
+	if err != nil {
+		t.Fatalf("CreateTaxClass: %v", err)
+	}
+
+	_, err = taxes.CreateTaxClass(model.TaxClass{Code: "VAT", Name: "Another VAT", Rate: 1200})
+	if !errors.Is(err, common.ErrExists) {
+		t.Errorf("CreateTaxClass with taken code error = %v, want ErrExists", err)
+	}
+
+	err = db.Exec("INSERT INTO products (name, tax_class_id) VALUES ('Kopi', 0)").Error
+	if err == nil {
+		t.Errorf("insert product with tax class 0 succeeded, want a foreign key violation")
+	}
+}
@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"fmt"
+	"learn/common"
+	"learn/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository interface {
+	// Notification
+	FindNotificationsByUserId(userId int) ([]model.Notification, error)
+	MarkNotificationRead(notificationId int, userId int) error
+	// Stock Subscription
+	CreateStockSubscription(subscription model.StockSubscription) (model.StockSubscription, error)
+	FindPendingStockSubscriptions(productId int) ([]model.StockSubscription, error)
+	MarkStockSubscriptionsNotified(subscriptionIds []int, notifications []model.Notification) ([]int, error)
+	// Low Stock
+	FindLowStockProducts() ([]model.Product, error)
+	MarkLowStockAlerted(productIds []int, notifications []model.Notification) ([]int, error)
+	ResetLowStockAlerts() error
+}
+
+type notificationRepository struct {
+	DB *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{
+		DB: db,
+	}
+}
+
+var (
+	emptyNotifications      = []model.Notification{}
+	emptyStockSubscription  = model.StockSubscription{}
+	emptyStockSubscriptions = []model.StockSubscription{}
+)
+
+// FindNotificationsByUserId implements NotificationRepository
+func (r *notificationRepository) FindNotificationsByUserId(userId int) ([]model.Notification, error) {
+	notifications := []model.Notification{}
+
+	err := r.DB.Where("user_id = ?", userId).Order("id desc").Find(&notifications).Error
+	if err != nil {
+		return emptyNotifications, fmt.Errorf("notification user %d: %w", userId, err)
+	}
+
+	return notifications, nil
+}
+
+// MarkNotificationRead implements NotificationRepository
+func (r *notificationRepository) MarkNotificationRead(notificationId int, userId int) error {
+	result := r.DB.Model(&model.Notification{}).
+		Where("id = ? AND user_id = ?", notificationId, userId).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("notification %d: %w", notificationId, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("notification %d: %w", notificationId, common.ErrNotFound)
+	}
+
+	return nil
+}
+
+// CreateStockSubscription implements NotificationRepository. A customer
+// already waiting for the product keeps the existing subscription.
+func (r *notificationRepository) CreateStockSubscription(subscription model.StockSubscription) (model.StockSubscription, error) {
+	err := r.DB.
+		Where("product_id = ? AND user_id = ? AND notified_at IS NULL", subscription.ProductId, subscription.UserId).
+		FirstOrCreate(&subscription).Error
+	if err != nil {
+		return emptyStockSubscription, fmt.Errorf("stock subscription: %w", translateError(err))
+	}
+
+	return subscription, nil
+}
+
+// FindPendingStockSubscriptions implements NotificationRepository
+func (r *notificationRepository) FindPendingStockSubscriptions(productId int) ([]model.StockSubscription, error) {
+	subscriptions := []model.StockSubscription{}
+
+	err := r.DB.Where("product_id = ? AND notified_at IS NULL", productId).Find(&subscriptions).Error
+	if err != nil {
+		return emptyStockSubscriptions, fmt.Errorf("stock subscription product %d: %w", productId, err)
+	}
+
+	return subscriptions, nil
+}
+
+// MarkStockSubscriptionsNotified implements NotificationRepository. The
+// subscriptions still pending are locked, marked and their customers'
+// notifications saved in one transaction, so each customer is notified
+// once even when two stock changes race. It returns the ids of the
+// subscriptions it marked.
+func (r *notificationRepository) MarkStockSubscriptionsNotified(subscriptionIds []int, notifications []model.Notification) ([]int, error) {
+	if len(subscriptionIds) == 0 {
+		return []int{}, nil
+	}
+
+	marked := []int{}
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		subscriptions := []model.StockSubscription{}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ? AND notified_at IS NULL", subscriptionIds).
+			Find(&subscriptions).Error
+		if err != nil || len(subscriptions) == 0 {
+			return err
+		}
+
+		users := map[int]bool{}
+		for _, subscription := range subscriptions {
+			marked = append(marked, subscription.Id)
+			users[subscription.UserId] = true
+		}
+
+		err = tx.Model(&model.StockSubscription{}).Where("id IN ?", marked).Update("notified_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+
+		return createNotifications(tx, notifications, func(n model.Notification) bool { return users[n.UserId] })
+	})
+	if err != nil {
+		return []int{}, fmt.Errorf("stock subscription: %w", translateError(err))
+	}
+
+	return marked, nil
+}
+
+// FindLowStockProducts implements NotificationRepository. Products already
+// alerted are skipped until their stock recovers.
+func (r *notificationRepository) FindLowStockProducts() ([]model.Product, error) {
+	products := []model.Product{}
+
+	err := r.DB.
+		Where("low_stock_threshold > 0 AND quantity <= low_stock_threshold AND low_stock_alerted_at IS NULL").
+		Find(&products).Error
+	if err != nil {
+		return empryProducts, fmt.Errorf("low stock product: %w", translateError(err))
+	}
+
+	return products, nil
+}
+
+// MarkLowStockAlerted implements NotificationRepository. The products not
+// alerted yet are locked, marked and their notifications saved in one
+// transaction, so an alert is neither lost nor repeated when two checks
+// overlap. It returns the ids of the products it marked.
+func (r *notificationRepository) MarkLowStockAlerted(productIds []int, notifications []model.Notification) ([]int, error) {
+	if len(productIds) == 0 {
+		return []int{}, nil
+	}
+
+	marked := []int{}
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.Product{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ? AND low_stock_alerted_at IS NULL", productIds).
+			Pluck("id", &marked).Error
+		if err != nil || len(marked) == 0 {
+			return err
+		}
+
+		err = tx.Model(&model.Product{}).Where("id IN ?", marked).Update("low_stock_alerted_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+
+		products := map[int]bool{}
+		for _, id := range marked {
+			products[id] = true
+		}
+
+		return createNotifications(tx, notifications, func(n model.Notification) bool { return products[n.ProductId] })
+	})
+	if err != nil {
+		return []int{}, fmt.Errorf("low stock product: %w", translateError(err))
+	}
+
+	return marked, nil
+}
+
+// ResetLowStockAlerts implements NotificationRepository
+func (r *notificationRepository) ResetLowStockAlerts() error {
+	err := r.DB.Model(&model.Product{}).
+		Where("low_stock_alerted_at IS NOT NULL AND quantity > low_stock_threshold").
+		Update("low_stock_alerted_at", nil).Error
+	if err != nil {
+		return fmt.Errorf("low stock product: %w", translateError(err))
+	}
+
+	return nil
+}
+
+// createNotifications saves the notifications keep accepts inside tx.
+func createNotifications(tx *gorm.DB, notifications []model.Notification, keep func(model.Notification) bool) error {
+	kept := []model.Notification{}
+	for _, notification := range notifications {
+		if keep(notification) {
+			kept = append(kept, notification)
+		}
+	}
+
+	if len(kept) == 0 {
+		return nil
+	}
+
+	return tx.Create(&kept).Error
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"fmt"
+	"learn/model"
+
+	"gorm.io/gorm"
+)
+
+type ProductImportRepository interface {
+	CreateJob(job model.ProductImportJob) (model.ProductImportJob, error)
+	UpdateJob(job model.ProductImportJob) (model.ProductImportJob, error)
+	FindJobById(jobId int) (model.ProductImportJob, error)
+}
+
+type productImportRepository struct {
+	DB *gorm.DB
+}
+
+func NewProductImportRepository(db *gorm.DB) ProductImportRepository {
+	return &productImportRepository{
+		DB: db,
+	}
+}
+
+var (
+	emptyProductImportJob = model.ProductImportJob{}
+)
+
+// CreateJob implements ProductImportRepository
+func (r *productImportRepository) CreateJob(job model.ProductImportJob) (model.ProductImportJob, error) {
+	err := r.DB.Create(&job).Error
+	if err != nil {
+		return emptyProductImportJob, fmt.Errorf("product import job: %w", translateError(err))
+	}
+
+	return job, nil
+}
+
+// UpdateJob implements ProductImportRepository
+func (r *productImportRepository) UpdateJob(job model.ProductImportJob) (model.ProductImportJob, error) {
+	err := r.DB.Save(&job).Error
+	if err != nil {
+		return emptyProductImportJob, fmt.Errorf("product import job %d: %w", job.Id, err)
+	}
+
+	return job, nil
+}
+
+// FindJobById implements ProductImportRepository
+func (r *productImportRepository) FindJobById(jobId int) (model.ProductImportJob, error) {
+	job := model.ProductImportJob{}
+
+	err := r.DB.Where("id = ?", jobId).First(&job).Error
+	if err != nil {
+		return emptyProductImportJob, fmt.Errorf("product import job %d: %w", jobId, err)
+	}
+
+	return job, nil
+}
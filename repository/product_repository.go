@@ -1,29 +1,43 @@
 package repository
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"learn/common"
 	"learn/model"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductRepository interface {
 	//Product
-	CreateProduct(product model.Product) (model.Product, error)
-	FindProductById(productId int) (model.Product, error)
-	UpdateProduct(product model.Product) (model.Product, error)
-	DeleteProduct(productId int) error
+	CreateProduct(ctx context.Context, product model.Product, stock model.InventoryMovement) (model.Product, model.InventoryMovement, error)
+	FindProductById(ctx context.Context, productId int) (model.Product, error)
+	UpdateProduct(ctx context.Context, product model.Product, stock model.InventoryMovement) (model.Product, model.InventoryMovement, error)
+	DeleteProduct(ctx context.Context, productId int) error
+	FindProductBySlug(ctx context.Context, slug string) (model.Product, error)
+	FindProductBySku(ctx context.Context, sku string) (model.Product, error)
+	FindProductByBarcode(ctx context.Context, barcode string) (model.Product, error)
+	FindProductDetailById(ctx context.Context, productId int) (model.Product, error)
+	FindRelatedProducts(ctx context.Context, product model.Product, limit int) ([]model.Product, error)
+	FindSlugHistory(ctx context.Context, slug string) (model.ProductSlug, error)
+	IsSlugTaken(ctx context.Context, slug string, productId int) (bool, error)
+	FindTrashedProducts(ctx context.Context) ([]model.Product, error)
+	RestoreProduct(ctx context.Context, productId int) error
+	PurgeTrashedProducts(ctx context.Context, deletedBefore time.Time) (int64, []string, error)
 	//Product Image
-	FindAllProductImagesByProductId(productId int) ([]model.ProductImage, error)
-	CreateProductImages(productImages model.ProductImage) (model.ProductImage, error)
-	MarkAllProductImagesNonPrimary(productId int) (bool, error)
-	DeleteProductImageById(prodImgId int) error
-	UpdateProductImageById(productImage model.ProductImage) (model.ProductImage, error)
+	FindAllProductImagesByProductId(ctx context.Context, productId int) ([]model.ProductImage, error)
+	FindProductImageById(ctx context.Context, prodImgId int) (model.ProductImage, error)
+	ReorderProductImages(ctx context.Context, productId int, prodImgIds []int) error
+	CreateProductImages(ctx context.Context, productImages model.ProductImage, imageFile model.ImageFile) (model.ProductImage, error)
+	MarkAllProductImagesNonPrimary(ctx context.Context, productId int) (bool, error)
+	DeleteProductImageById(ctx context.Context, prodImgId int) error
+	UpdateProductImageById(ctx context.Context, productImage model.ProductImage) (model.ProductImage, error)
 
 	// USER
-	FindAllProduct() ([]model.Product, error)
+	FindAllProduct(ctx context.Context) ([]model.Product, error)
 }
 
 type productRepository struct {
@@ -43,126 +57,497 @@ var (
 	emptyProductImage  = model.ProductImage{}
 )
 
-// CreateProduct implements ProductRepository
-func (r *productRepository) CreateProduct(product model.Product) (model.Product, error) {
-	err := r.DB.Create(&product).Error
-	if err != nil {
-		if errors.Is(err, common.ErrFailedCreateData) {
-			return emptyProduct, fmt.Errorf("product: %w", common.ErrFailedCreateData)
+// CreateProduct implements ProductRepository. The product starts without
+// stock, and product.Quantity is booked in the same transaction as the
+// movement stock describes, so the ledger opens with it.
+func (r *productRepository) CreateProduct(ctx context.Context, product model.Product, stock model.InventoryMovement) (model.Product, model.InventoryMovement, error) {
+	quantity := product.Quantity
+	product.Quantity = 0
+
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&product).Error
+		if err != nil {
+			return err
 		}
+
+		product, stock, err = setStock(tx, product, quantity, stock)
+		return err
+	})
+	if err != nil {
+		return emptyProduct, emptyInventoryMovement, fmt.Errorf("product %s: %w", product.Name, translateError(err))
 	}
 
-	return product, nil
+	return product, stock, nil
 }
 
 // FindProductById implements ProductRepository
-func (r *productRepository) FindProductById(productId int) (model.Product, error) {
+func (r *productRepository) FindProductById(ctx context.Context, productId int) (model.Product, error) {
 	product := model.Product{}
 
-	err := r.DB.Where("id = ?", productId).Find(&product).Error
+	err := r.DB.WithContext(ctx).Preload("TaxClass").Preload("WarehouseStocks").Where("id = ?", productId).First(&product).Error
 	if err != nil {
-		if errors.Is(err, common.ErrNotFound) {
-			return emptyProduct, fmt.Errorf("product %d: %w", productId, common.ErrNotFound)
-		}
+		return emptyProduct, fmt.Errorf("product %d: %w", productId, translateError(err))
 	}
 
 	return product, nil
 }
 
 // UpdateProduct implements ProductRepository
-func (r *productRepository) UpdateProduct(product model.Product) (model.Product, error) {
-	err := r.DB.Save(&product).Error
+// The product row is locked for the update. A product.Quantity that differs
+// from the stored one is booked in the same transaction as the movement
+// stock describes, so a failed update changes no stock, and a changed slug
+// is added to the slug history with it. WarehouseStocks are left out, and
+// LowStockAlertedAt belongs to the low-stock checker.
+func (r *productRepository) UpdateProduct(ctx context.Context, product model.Product, stock model.InventoryMovement) (model.Product, model.InventoryMovement, error) {
+	quantity := product.Quantity
+
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current := model.Product{}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", product.Id).First(&current).Error
+		if err != nil {
+			return err
+		}
+
+		product.Quantity = current.Quantity
+
+		err = tx.Omit("Quantity", "WarehouseStocks", "LowStockAlertedAt").Save(&product).Error
+		if err != nil {
+			return err
+		}
+
+		if product.Slug != current.Slug {
+			err = saveSlugHistory(tx, product.Id, current.Slug, product.Slug)
+			if err != nil {
+				return err
+			}
+		}
+
+		product, stock, err = setStock(tx, product, quantity, stock)
+		return err
+	})
+	if err != nil {
+		return emptyProduct, emptyInventoryMovement, fmt.Errorf("product %d: %w", product.Id, translateError(err))
+	}
+
+	return product, stock, nil
+}
+
+// setStock books the difference between product.Quantity and quantity as
+// stock, a movement whose type, reason and user the caller chose. The
+// movement is zero when there is no difference.
+func setStock(tx *gorm.DB, product model.Product, quantity int, stock model.InventoryMovement) (model.Product, model.InventoryMovement, error) {
+	if quantity == product.Quantity {
+		return product, emptyInventoryMovement, nil
+	}
+
+	stock.ProductId = product.Id
+	stock.Quantity = quantity - product.Quantity
+
+	stock, err := bookMovement(tx, stock)
 	if err != nil {
-		if errors.Is(err, common.ErrFailedUpdateData) {
-			return emptyProduct, fmt.Errorf("product : %w", common.ErrFailedUpdateData)
+		return emptyProduct, emptyInventoryMovement, err
+	}
+
+	product.Quantity = stock.BalanceAfter
+	return product, stock, nil
+}
+
+// DeleteProduct implements ProductRepository. The product and its images
+// are soft deleted with the same timestamp so a restore can bring back
+// exactly the images that went to the trash with it.
+func (r *productRepository) DeleteProduct(ctx context.Context, productId int) error {
+	now := time.Now()
+
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.ProductImage{}).Where("product_id = ?", productId).Update("deleted_at", now).Error
+		if err != nil {
+			return err
 		}
+
+		result := tx.Model(&model.Product{}).Where("id = ?", productId).Update("deleted_at", now)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return common.ErrNotFound
+		}
+
+		return result.Error
+	})
+	if err != nil {
+		return fmt.Errorf("product %d: %w", productId, err)
+	}
+
+	return nil
+}
+
+// detail loads what a product detail page shows: all images, primary
+// first, plus tax class and stock locations.
+func (r *productRepository) detail(ctx context.Context) *gorm.DB {
+	return r.DB.WithContext(ctx).Preload("TaxClass").Preload("WarehouseStocks").
+		Preload("ProductImages", orderImages)
+}
+
+// orderImages sorts a gallery: the primary image first, then by position.
+func orderImages(db *gorm.DB) *gorm.DB {
+	return db.Order("is_primary = 'yes' desc").Order("position").Order("id")
+}
+
+// FindProductBySlug implements ProductRepository
+func (r *productRepository) FindProductBySlug(ctx context.Context, slug string) (model.Product, error) {
+	product := model.Product{}
+
+	err := r.detail(ctx).Where("slug = ?", slug).First(&product).Error
+	if err != nil {
+		return emptyProduct, fmt.Errorf("product %s: %w", slug, translateError(err))
 	}
 
 	return product, nil
 }
 
-// DeleteProduct implements ProductRepository
-func (r *productRepository) DeleteProduct(productId int) error {
-	var product model.Product
-	var productImage model.ProductImage
+// FindProductDetailById implements ProductRepository
+func (r *productRepository) FindProductDetailById(ctx context.Context, productId int) (model.Product, error) {
+	product := model.Product{}
 
-	productImages := []model.ProductImage{}
-	err := r.DB.Where("product_id = ?", productId).Find(&productImages).Delete(&productImage).Error
+	err := r.detail(ctx).Where("id = ?", productId).First(&product).Error
 	if err != nil {
-		if errors.Is(err, common.ErrDeleteData) {
-			return fmt.Errorf("product %d: %w", productId, common.ErrDeleteData)
-		}
+		return emptyProduct, fmt.Errorf("product %d: %w", productId, translateError(err))
+	}
+
+	return product, nil
+}
+
+// FindRelatedProducts implements ProductRepository. Without categories the
+// closest match is other in-stock products priced nearest to product.
+func (r *productRepository) FindRelatedProducts(ctx context.Context, product model.Product, limit int) ([]model.Product, error) {
+	products := []model.Product{}
+
+	err := r.DB.WithContext(ctx).Preload("TaxClass").Preload("ProductImages", "product_images.is_primary = ?", "yes").
+		Where("id <> ? AND quantity > 0 AND price_currency = ?", product.Id, product.Price.Currency).
+		Clauses(clause.OrderBy{Expression: clause.Expr{SQL: "ABS(price_amount - ?)", Vars: []interface{}{product.Price.Amount}}}).
+		Limit(limit).
+		Find(&products).Error
+	if err != nil {
+		return empryProducts, fmt.Errorf("related product %d: %w", product.Id, err)
+	}
+
+	return products, nil
+}
+
+// FindProductBySku implements ProductRepository. Trashed products are
+// included because they still hold their SKU.
+func (r *productRepository) FindProductBySku(ctx context.Context, sku string) (model.Product, error) {
+	product := model.Product{}
+
+	err := r.DB.WithContext(ctx).Unscoped().Preload("TaxClass").Preload("WarehouseStocks").Where("sku = ?", sku).First(&product).Error
+	if err != nil {
+		return emptyProduct, fmt.Errorf("product sku %s: %w", sku, translateError(err))
+	}
+
+	return product, nil
+}
+
+// FindProductByBarcode implements ProductRepository. Trashed products are
+// included because they still hold their barcode.
+func (r *productRepository) FindProductByBarcode(ctx context.Context, barcode string) (model.Product, error) {
+	product := model.Product{}
+
+	err := r.DB.WithContext(ctx).Unscoped().Preload("TaxClass").Preload("WarehouseStocks").Where("barcode = ?", barcode).First(&product).Error
+	if err != nil {
+		return emptyProduct, fmt.Errorf("product barcode %s: %w", barcode, translateError(err))
+	}
+
+	return product, nil
+}
+
+// FindSlugHistory implements ProductRepository
+func (r *productRepository) FindSlugHistory(ctx context.Context, slug string) (model.ProductSlug, error) {
+	productSlug := model.ProductSlug{}
+
+	err := r.DB.WithContext(ctx).Where("slug = ?", slug).First(&productSlug).Error
+	if err != nil {
+		return model.ProductSlug{}, fmt.Errorf("product slug %s: %w", slug, translateError(err))
+	}
+
+	return productSlug, nil
+}
+
+// IsSlugTaken implements ProductRepository. A slug is taken when another
+// product, trashed or not, uses it now or used it before.
+func (r *productRepository) IsSlugTaken(ctx context.Context, slug string, productId int) (bool, error) {
+	var count int64
+
+	err := r.DB.WithContext(ctx).Unscoped().Model(&model.Product{}).Where("slug = ? AND id <> ?", slug, productId).Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("product slug %s: %w", slug, err)
+	}
+
+	if count > 0 {
+		return true, nil
+	}
+
+	err = r.DB.WithContext(ctx).Model(&model.ProductSlug{}).Where("slug = ? AND product_id <> ?", slug, productId).Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("product slug %s: %w", slug, err)
+	}
+
+	return count > 0, nil
+}
+
+// saveSlugHistory records a slug change inside tx. The old slug keeps
+// redirecting to the product; taking back a previous slug removes it from
+// the history.
+func saveSlugHistory(tx *gorm.DB, productId int, oldSlug string, newSlug string) error {
+	err := tx.Where("product_id = ? AND slug = ?", productId, newSlug).Delete(&model.ProductSlug{}).Error
+	if err != nil {
+		return err
+	}
+
+	if oldSlug == "" {
+		return nil
 	}
 
-	err = r.DB.Delete(&product, productId).Error
+	productSlug := model.ProductSlug{ProductId: productId, Slug: oldSlug}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&productSlug).Error
+}
+
+// FindTrashedProducts implements ProductRepository
+func (r *productRepository) FindTrashedProducts(ctx context.Context) ([]model.Product, error) {
+	products := []model.Product{}
+
+	err := r.DB.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at desc").Find(&products).Error
 	if err != nil {
-		if errors.Is(err, common.ErrNotFound) {
-			return fmt.Errorf("product %d: %w", productId, common.ErrNotFound)
+		return empryProducts, fmt.Errorf("product trash: %w", err)
+	}
+
+	return products, nil
+}
+
+// RestoreProduct implements ProductRepository
+func (r *productRepository) RestoreProduct(ctx context.Context, productId int) error {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		product := model.Product{}
+
+		err := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", productId).First(&product).Error
+		if err != nil {
+			return translateError(err)
+		}
+
+		err = tx.Unscoped().Model(&model.ProductImage{}).
+			Where("product_id = ? AND deleted_at = ?", productId, product.DeletedAt).
+			Update("deleted_at", nil).Error
+		if err != nil {
+			return err
 		}
+
+		return tx.Unscoped().Model(&product).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		return fmt.Errorf("product %d: %w", productId, translateError(err))
 	}
 
 	return nil
 }
 
+// PurgeTrashedProducts implements ProductRepository. It permanently removes
+// products and images that have been in the trash since before
+// deletedBefore, and returns the number of purged products and the stored
+// files no image uses any more. The rows that refer to a purged product
+// are removed with it, in the same transaction, rather than left to the
+// foreign keys.
+func (r *productRepository) PurgeTrashedProducts(ctx context.Context, deletedBefore time.Time) (int64, []string, error) {
+	var purged int64
+	unusedFiles := []string{}
+
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		productIds := []int{}
+
+		err := tx.Unscoped().Model(&model.Product{}).Where("deleted_at < ?", deletedBefore).Pluck("id", &productIds).Error
+		if err != nil {
+			return err
+		}
+
+		images := tx.Unscoped().Where("deleted_at < ? OR product_id IN ?", deletedBefore, append(productIds, 0)).Session(&gorm.Session{})
+
+		fileRefs := []struct {
+			ContentHash string
+			Refs        int
+		}{}
+
+		err = images.Model(&model.ProductImage{}).
+			Select("content_hash, COUNT(*) AS refs").
+			Where("content_hash <> ''").
+			Group("content_hash").
+			Scan(&fileRefs).Error
+		if err != nil {
+			return err
+		}
+
+		err = images.Delete(&model.ProductImage{}).Error
+		if err != nil {
+			return err
+		}
+
+		hashes := []string{}
+		for _, fileRef := range fileRefs {
+			err = tx.Model(&model.ImageFile{}).Where("hash = ?", fileRef.ContentHash).
+				Update("ref_count", gorm.Expr("ref_count - ?", fileRef.Refs)).Error
+			if err != nil {
+				return err
+			}
+
+			hashes = append(hashes, fileRef.ContentHash)
+		}
+
+		if len(hashes) > 0 {
+			unused := []model.ImageFile{}
+
+			err = tx.Clauses(clause.Returning{}).Where("hash IN ? AND ref_count <= 0", hashes).Delete(&unused).Error
+			if err != nil {
+				return err
+			}
+
+			for _, imageFile := range unused {
+				unusedFiles = append(unusedFiles, imageFile.FileName)
+			}
+		}
+
+		if len(productIds) == 0 {
+			return nil
+		}
+
+		for _, dependent := range []interface{}{
+			&model.WarehouseStock{},
+			&model.InventoryMovement{},
+			&model.StockTransfer{},
+			&model.StockSubscription{},
+			&model.Notification{},
+			&model.ProductSlug{},
+		} {
+			err = tx.Where("product_id IN ?", productIds).Delete(dependent).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		result := tx.Unscoped().Where("id IN ?", productIds).Delete(&model.Product{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return 0, nil, fmt.Errorf("product trash: %w", err)
+	}
+
+	return purged, unusedFiles, nil
+}
+
 // FindAllProductImagesByProductId implements ProductRepository
-func (r *productRepository) FindAllProductImagesByProductId(productId int) ([]model.ProductImage, error) {
+func (r *productRepository) FindAllProductImagesByProductId(ctx context.Context, productId int) ([]model.ProductImage, error) {
 	productImage := []model.ProductImage{}
 
-	err := r.DB.Where("product_id = ?", productId).Find(&productImage).Error
+	err := r.DB.WithContext(ctx).Scopes(orderImages).Where("product_id = ?", productId).Find(&productImage).Error
 	if err != nil {
-		if errors.Is(err, common.ErrNotFound) {
-			return emptyProductImages, fmt.Errorf("product %d: %w", productId, common.ErrNotFound)
-		}
+		return emptyProductImages, fmt.Errorf("product %d images: %w", productId, err)
 	}
 
 	return productImage, nil
 }
 
-// CreateProductImages implements ProductRepository
-func (r *productRepository) CreateProductImages(productImages model.ProductImage) (model.ProductImage, error) {
-	var productImage model.ProductImage
+// FindProductImageById implements ProductRepository
+func (r *productRepository) FindProductImageById(ctx context.Context, prodImgId int) (model.ProductImage, error) {
+	productImage := model.ProductImage{}
 
-	err := r.DB.Create(&productImages).Error
+	err := r.DB.WithContext(ctx).Where("id = ?", prodImgId).First(&productImage).Error
 	if err != nil {
-		if errors.Is(err, common.ErrNotFound) {
-			return emptyProductImage, fmt.Errorf("product %w", common.ErrNotFound)
-		}
+		return emptyProductImage, fmt.Errorf("product image %d: %w", prodImgId, translateError(err))
 	}
 
 	return productImage, nil
 }
 
+// ReorderProductImages implements ProductRepository. Images get positions
+// 1..n in the order of prodImgIds.
+func (r *productRepository) ReorderProductImages(ctx context.Context, productId int, prodImgIds []int) error {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, prodImgId := range prodImgIds {
+			err := tx.Model(&model.ProductImage{}).
+				Where("id = ? AND product_id = ?", prodImgId, productId).
+				Update("position", i+1).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("product %d images: %w", productId, err)
+	}
+
+	return nil
+}
+
+// CreateProductImages implements ProductRepository. The image takes a
+// reference on its stored file, which is recorded on first use. A new
+// primary image replaces the product's primary in the same transaction.
+func (r *productRepository) CreateProductImages(ctx context.Context, productImages model.ProductImage, imageFile model.ImageFile) (model.ProductImage, error) {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if productImages.IsPrimary == "yes" {
+			err := tx.Model(&model.ProductImage{}).Where("product_id = ?", productImages.ProductId).Update("is_primary", "no").Error
+			if err != nil {
+				return err
+			}
+		}
+
+		imageFile.RefCount = 1
+
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "hash"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"ref_count":  gorm.Expr("image_files.ref_count + 1"),
+				"updated_at": time.Now(),
+			}),
+		}).Create(&imageFile).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&productImages).Error
+	})
+	if err != nil {
+		return emptyProductImage, fmt.Errorf("product %d image: %w", productImages.ProductId, translateError(err))
+	}
+
+	return productImages, nil
+}
+
 // MarkAllProductImagesNonPrimary implements ProductRepository
-func (r *productRepository) MarkAllProductImagesNonPrimary(productId int) (bool, error) {
-	err := r.DB.Model(&model.ProductImage{}).Where("product_id = ?", productId).Update("is_primary", "no").Error
+func (r *productRepository) MarkAllProductImagesNonPrimary(ctx context.Context, productId int) (bool, error) {
+	err := r.DB.WithContext(ctx).Model(&model.ProductImage{}).Where("product_id = ?", productId).Update("is_primary", "no").Error
 	if err != nil {
-		return false, err
+		return false, fmt.Errorf("product %d images: %w", productId, err)
 	}
 
 	return true, nil
 }
 
-// FindProductImageById implements ProductRepository
-func (r *productRepository) DeleteProductImageById(prodImgId int) error {
-	productImage := model.ProductImage{}
+// DeleteProductImageById implements ProductRepository
+func (r *productRepository) DeleteProductImageById(ctx context.Context, prodImgId int) error {
+	result := r.DB.WithContext(ctx).Delete(&model.ProductImage{}, prodImgId)
+	if result.Error != nil {
+		return fmt.Errorf("product image %d: %w", prodImgId, result.Error)
+	}
 
-	err := r.DB.Delete(&productImage, prodImgId).Error
-	if err != nil {
-		if errors.Is(err, common.ErrNotFound) {
-			return fmt.Errorf("product %d: %w", prodImgId, common.ErrNotFound)
-		}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("product image %d: %w", prodImgId, common.ErrNotFound)
 	}
 
 	return nil
 }
 
 // UpdateProductImageById implements ProductRepository
-func (r *productRepository) UpdateProductImageById(productImage model.ProductImage) (model.ProductImage, error) {
-	err := r.DB.Save(&productImage).Error
+func (r *productRepository) UpdateProductImageById(ctx context.Context, productImage model.ProductImage) (model.ProductImage, error) {
+	err := r.DB.WithContext(ctx).Save(&productImage).Error
 	if err != nil {
-		if errors.Is(err, common.ErrFailedUpdateData) {
-			return emptyProductImage, fmt.Errorf("product : %w", common.ErrFailedUpdateData)
-		}
+		return emptyProductImage, fmt.Errorf("product image %d: %w", productImage.Id, translateError(err))
 	}
 
 	return productImage, nil
@@ -170,14 +555,12 @@ func (r *productRepository) UpdateProductImageById(productImage model.ProductIma
 
 // / USER
 // FindAllProduct implements ProductRepository
-func (r *productRepository) FindAllProduct() ([]model.Product, error) {
+func (r *productRepository) FindAllProduct(ctx context.Context) ([]model.Product, error) {
 	products := []model.Product{}
 
-	err := r.DB.Model(&model.Product{}).Preload("ProductImages", "product_images.is_primary = ?", "yes").Find(&products).Error
+	err := r.DB.WithContext(ctx).Model(&model.Product{}).Preload("TaxClass").Preload("WarehouseStocks").Preload("ProductImages", "product_images.is_primary = ?", "yes").Find(&products).Error
 	if err != nil {
-		if errors.Is(err, common.ErrNotFound) {
-			return empryProducts, fmt.Errorf("product : %w", common.ErrNotFound)
-		}
+		return empryProducts, fmt.Errorf("product: %w", err)
 	}
 
 	return products, nil
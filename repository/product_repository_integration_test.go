@@ -0,0 +1,231 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"learn/common"
+	"learn/model"
+	"learn/repository"
+	"learn/repository/repotest"
+	"testing"
+	"time"
+)
+
+// openingStock is how the tests book the quantity a product starts with.
+var openingStock = model.InventoryMovement{Type: model.MovementRestock, Reason: "initial stock", UserId: 1}
+
+func TestProductRepositoryUniqueSku(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewProductRepository(newDB(t))
+
+	product, _, err := repo.CreateProduct(ctx, repotest.NewProduct(), openingStock)
+	if err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+
+	_, _, err = repo.CreateProduct(ctx, repotest.NewProduct(func(p *model.Product) { p.Sku = product.Sku }), openingStock)
+	if !errors.Is(err, common.ErrExists) {
+		t.Errorf("CreateProduct with taken sku error = %v, want ErrExists", err)
+	}
+
+	// Products without a SKU don't collide with each other.
+	for i := 0; i < 2; i++ {
+		_, _, err = repo.CreateProduct(ctx, repotest.NewProduct(func(p *model.Product) { p.Sku = "" }), openingStock)
+		if err != nil {
+			t.Fatalf("CreateProduct without sku: %v", err)
+		}
+	}
+}
+
+func TestProductRepositoryUpdateStock(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := repository.NewProductRepository(db)
+	inventory := repository.NewInventoryRepository(db)
+
+	product, _, err := repo.CreateProduct(ctx, repotest.NewProduct(), openingStock)
+	if err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+	other, _, err := repo.CreateProduct(ctx, repotest.NewProduct(), openingStock)
+	if err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+
+	stock := model.InventoryMovement{Type: model.MovementAdjustment, Reason: "product update", UserId: 7}
+
+	taken := product
+	taken.Sku = other.Sku
+	taken.Quantity = product.Quantity + 5
+	_, _, err = repo.UpdateProduct(ctx, taken, stock)
+	if !errors.Is(err, common.ErrExists) {
+		t.Fatalf("UpdateProduct with taken sku error = %v, want ErrExists", err)
+	}
+
+	movements, err := inventory.FindMovementsByProductId(product.Id)
+	if err != nil || len(movements) != 1 {
+		t.Fatalf("movements after failed update = %d, %v, want only the opening balance", len(movements), err)
+	}
+
+	product.Quantity += 5
+	updated, movement, err := repo.UpdateProduct(ctx, product, stock)
+	if err != nil {
+		t.Fatalf("UpdateProduct: %v", err)
+	}
+	if updated.Quantity != product.Quantity || movement.Quantity != 5 || movement.UserId != 7 {
+		t.Errorf("UpdateProduct = quantity %d, movement %+v, want quantity %d booked by user 7", updated.Quantity, movement, product.Quantity)
+	}
+}
+
+func TestProductRepositorySlugHistory(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := repository.NewProductRepository(db)
+
+	product, _, err := repo.CreateProduct(ctx, repotest.NewProduct(), openingStock)
+	if err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+	other, _, err := repo.CreateProduct(ctx, repotest.NewProduct(), openingStock)
+	if err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+
+	oldSlug := product.Slug
+	product.Slug = oldSlug + "-renamed"
+
+	taken := product
+	taken.Sku = other.Sku
+	_, _, err = repo.UpdateProduct(ctx, taken, openingStock)
+	if !errors.Is(err, common.ErrExists) {
+		t.Fatalf("UpdateProduct with taken sku error = %v, want ErrExists", err)
+	}
+
+	var history int64
+	err = db.Model(&model.ProductSlug{}).Where("slug = ?", oldSlug).Count(&history).Error
+	if err != nil || history != 0 {
+		t.Fatalf("slug history after failed update = %d, %v, want none", history, err)
+	}
+
+	_, _, err = repo.UpdateProduct(ctx, product, openingStock)
+	if err != nil {
+		t.Fatalf("UpdateProduct: %v", err)
+	}
+
+	err = db.Model(&model.ProductSlug{}).Where("slug = ? AND product_id = ?", oldSlug, product.Id).Count(&history).Error
+	if err != nil || history != 1 {
+		t.Errorf("slug history after rename = %d, %v, want the old slug", history, err)
+	}
+}
+
+func TestProductRepositoryTrash(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewProductRepository(newDB(t))
+
+	product, _, err := repo.CreateProduct(ctx, repotest.NewProduct(), openingStock)
+	if err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+
+	image := model.ProductImage{ProductId: product.Id, FileName: "a.png", ContentHash: "hash-a", IsPrimary: "yes", Position: 1}
+	_, err = repo.CreateProductImages(ctx, image, model.ImageFile{Hash: "hash-a", FileName: "a.png"})
+	if err != nil {
+		t.Fatalf("CreateProductImages: %v", err)
+	}
+
+	err = repo.DeleteProduct(ctx, product.Id)
+	if err != nil {
+		t.Fatalf("DeleteProduct: %v", err)
+	}
+
+	_, err = repo.FindProductById(ctx, product.Id)
+	if !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("FindProductById of trashed product error = %v, want ErrNotFound", err)
+	}
+
+	trashed, err := repo.FindProductBySku(ctx, product.Sku)
+	if err != nil || !trashed.DeletedAt.Valid {
+		t.Errorf("FindProductBySku of trashed product = %v, %v, want the trashed product", trashed.DeletedAt, err)
+	}
+
+	err = repo.RestoreProduct(ctx, product.Id)
+	if err != nil {
+		t.Fatalf("RestoreProduct: %v", err)
+	}
+
+	restored, err := repo.FindProductDetailById(ctx, product.Id)
+	if err != nil {
+		t.Fatalf("FindProductDetailById: %v", err)
+	}
+	if len(restored.ProductImages) != 1 {
+		t.Errorf("restored product has %d images, want 1", len(restored.ProductImages))
+	}
+
+	err = repo.RestoreProduct(ctx, product.Id)
+	if !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("RestoreProduct of live product error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestProductRepositoryPurge(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := repository.NewProductRepository(db)
+
+	kept, _, err := repo.CreateProduct(ctx, repotest.NewProduct(), openingStock)
+	if err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+	purged, _, err := repo.CreateProduct(ctx, repotest.NewProduct(), openingStock)
+	if err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+
+	// Both products share one file; the other only has its own.
+	for _, image := range []model.ProductImage{
+		{ProductId: kept.Id, FileName: "shared.png", ContentHash: "shared", IsPrimary: "yes"},
+		{ProductId: purged.Id, FileName: "shared.png", ContentHash: "shared", IsPrimary: "yes"},
+		{ProductId: purged.Id, FileName: "own.png", ContentHash: "own", IsPrimary: "no"},
+	} {
+		_, err = repo.CreateProductImages(ctx, image, model.ImageFile{Hash: image.ContentHash, FileName: image.FileName})
+		if err != nil {
+			t.Fatalf("CreateProductImages: %v", err)
+		}
+	}
+
+	err = db.Create(&model.ProductSlug{ProductId: purged.Id, Slug: "old-" + purged.Slug}).Error
+	if err != nil {
+		t.Fatalf("create slug history: %v", err)
+	}
+
+	err = repo.DeleteProduct(ctx, purged.Id)
+	if err != nil {
+		t.Fatalf("DeleteProduct: %v", err)
+	}
+
+	count, unusedFiles, err := repo.PurgeTrashedProducts(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("PurgeTrashedProducts: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("purged %d products, want 1", count)
+	}
+	if len(unusedFiles) != 1 || unusedFiles[0] != "own.png" {
+		t.Errorf("unused files = %v, want [own.png]", unusedFiles)
+	}
+
+	_, err = repo.FindProductBySku(ctx, purged.Sku)
+	if !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("FindProductBySku of purged product error = %v, want ErrNotFound", err)
+	}
+
+	for _, table := range []string{"inventory_movements", "product_slugs"} {
+		var left int64
+		err = db.Table(table).Where("product_id = ?", purged.Id).Count(&left).Error
+		if err != nil || left != 0 {
+			t.Errorf("%s of purged product = %d, %v, want none", table, left, err)
+		}
+	}
+}
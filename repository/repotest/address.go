@@ -0,0 +1,118 @@
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"learn/common"
+	"learn/model"
+	"learn/repository"
+	"sync"
+	"time"
+)
+
+// AddressRepository is an in-memory repository.AddressRepository.
+type AddressRepository struct {
+	mu        sync.Mutex
+	addresses map[int]model.Address
+	nextId    int
+}
+
+var _ repository.AddressRepository = (*AddressRepository)(nil)
+
+// NewAddressRepository returns an AddressRepository holding addresses.
+func NewAddressRepository(addresses ...model.Address) *AddressRepository {
+	r := &AddressRepository{addresses: map[int]model.Address{}}
+	for _, address := range addresses {
+		r.put(address)
+	}
+
+	return r
+}
+
+func (r *AddressRepository) put(address model.Address) model.Address {
+	if address.Id == 0 {
+		r.nextId++
+		address.Id = r.nextId
+	} else if address.Id > r.nextId {
+		r.nextId = address.Id
+	}
+
+	r.addresses[address.Id] = address
+	return address
+}
+
+// Create implements repository.AddressRepository
+func (r *AddressRepository) Create(ctx context.Context, address model.Address) (model.Address, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	address.CreatedAt, address.UpdatedAt = now, now
+
+	return r.put(address), nil
+}
+
+// MarkAllAddressNonPrimary implements repository.AddressRepository
+func (r *AddressRepository) MarkAllAddressNonPrimary(ctx context.Context, userId int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, address := range r.addresses {
+		if address.UserId == userId {
+			address.IsPrimary = "no"
+			r.addresses[id] = address
+		}
+	}
+
+	return true, nil
+}
+
+// FindByUserId implements repository.AddressRepository
+func (r *AddressRepository) FindByUserId(ctx context.Context, userId int) ([]model.Address, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	addresses := []model.Address{}
+	for id := 1; id <= r.nextId; id++ {
+		if address, ok := r.addresses[id]; ok && address.UserId == userId {
+			addresses = append(addresses, address)
+		}
+	}
+
+	return addresses, nil
+}
+
+// FindByAddressId implements repository.AddressRepository
+func (r *AddressRepository) FindByAddressId(ctx context.Context, addressId int) (model.Address, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	address, ok := r.addresses[addressId]
+	if !ok {
+		return model.Address{}, fmt.Errorf("address %d: %w", addressId, common.ErrNotFound)
+	}
+
+	return address, nil
+}
+
+// Update implements repository.AddressRepository
+func (r *AddressRepository) Update(ctx context.Context, address model.Address) (model.Address, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	address.UpdatedAt = time.Now()
+	return r.put(address), nil
+}
+
+// Delete implements repository.AddressRepository
+func (r *AddressRepository) Delete(ctx context.Context, addressId int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.addresses[addressId]; !ok {
+		return fmt.Errorf("address %d: %w", addressId, common.ErrNotFound)
+	}
+
+	delete(r.addresses, addressId)
+	return nil
+}
@@ -0,0 +1,92 @@
+package repotest
+
+import (
+	"fmt"
+	"learn/model"
+	"sync/atomic"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UserPassword is the plain password of every user NewUser builds.
+const UserPassword = "Secret#123"
+
+// userPasswordHash is UserPassword hashed at the lowest cost so tests that
+// log in stay fast.
+var userPasswordHash = func() string {
+	hash, err := bcrypt.GenerateFromPassword([]byte(UserPassword), bcrypt.MinCost)
+	if err != nil {
+		panic(err)
+	}
+
+	return string(hash)
+}()
+
+// sequence makes the unique fields of built models unique within a test
+// binary.
+var sequence atomic.Int64
+
+func next() int64 {
+	return sequence.Add(1)
+}
+
+// NewUser builds a user with a unique username and email and the password
+// UserPassword. Overrides are applied in order.
+func NewUser(overrides ...func(*model.User)) model.User {
+	n := next()
+	user := model.User{
+		Username: fmt.Sprintf("user%d", n),
+		Email:    fmt.Sprintf("user%d@example.com", n),
+		Phone:    "+6281234567890",
+		Password: userPasswordHash,
+		Role:     "user",
+	}
+
+	for _, override := range overrides {
+		override(&user)
+	}
+
+	return user
+}
+
+// NewAdmin builds a user with the admin role.
+func NewAdmin(overrides ...func(*model.User)) model.User {
+	return NewUser(append([]func(*model.User){func(user *model.User) { user.Role = "admin" }}, overrides...)...)
+}
+
+// NewAddress builds a primary address of userId.
+func NewAddress(userId int, overrides ...func(*model.Address)) model.Address {
+	address := model.Address{
+		Address:    fmt.Sprintf("Jl. Merdeka No. %d, Jakarta", next()),
+		PostalCode: "10110",
+		IsPrimary:  "yes",
+		UserId:     userId,
+	}
+
+	for _, override := range overrides {
+		override(&address)
+	}
+
+	return address
+}
+
+// NewProduct builds an in-stock product priced in the base currency with a
+// unique SKU and slug.
+func NewProduct(overrides ...func(*model.Product)) model.Product {
+	n := next()
+	product := model.Product{
+		Sku:               fmt.Sprintf("SKU-%d", n),
+		Name:              fmt.Sprintf("Product %d", n),
+		Slug:              fmt.Sprintf("product-%d", n),
+		Description:       "A product built for tests",
+		Quantity:          10,
+		LowStockThreshold: 2,
+		Price:             model.Money{Amount: 150000, Currency: model.BaseCurrency},
+	}
+
+	for _, override := range overrides {
+		override(&product)
+	}
+
+	return product
+}
@@ -0,0 +1,646 @@
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"learn/common"
+	"learn/model"
+	"learn/repository"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProductRepository is an in-memory repository.ProductRepository. Deleted
+// products and images stay in the trash the way gorm soft deletes them, and
+// SKU, barcode and slug are unique across live and trashed products.
+type ProductRepository struct {
+	mu         sync.Mutex
+	products   map[int]model.Product
+	images     map[int]model.ProductImage
+	slugs      []model.ProductSlug
+	imageFiles map[string]model.ImageFile
+	movements  []model.InventoryMovement
+	nextId     int
+	nextImgId  int
+
+	nextMovementId int
+}
+
+var _ repository.ProductRepository = (*ProductRepository)(nil)
+
+// NewProductRepository returns a ProductRepository holding products.
+func NewProductRepository(products ...model.Product) *ProductRepository {
+	r := &ProductRepository{
+		products:   map[int]model.Product{},
+		images:     map[int]model.ProductImage{},
+		imageFiles: map[string]model.ImageFile{},
+	}
+	for _, product := range products {
+		r.put(product)
+	}
+
+	return r
+}
+
+func (r *ProductRepository) put(product model.Product) model.Product {
+	if product.Id == 0 {
+		r.nextId++
+		product.Id = r.nextId
+	} else if product.Id > r.nextId {
+		r.nextId = product.Id
+	}
+
+	images := product.ProductImages
+	product.ProductImages = nil
+	r.products[product.Id] = product
+
+	for _, image := range images {
+		image.ProductId = product.Id
+		r.putImage(image)
+	}
+
+	return product
+}
+
+func (r *ProductRepository) putImage(image model.ProductImage) model.ProductImage {
+	if image.Id == 0 {
+		r.nextImgId++
+		image.Id = r.nextImgId
+	} else if image.Id > r.nextImgId {
+		r.nextImgId = image.Id
+	}
+
+	r.images[image.Id] = image
+	return image
+}
+
+// checkUnique returns ErrExists when another product holds product's SKU,
+// barcode or slug.
+func (r *ProductRepository) checkUnique(product model.Product) error {
+	for _, other := range r.products {
+		if other.Id == product.Id {
+			continue
+		}
+
+		switch {
+		case product.Sku != "" && other.Sku == product.Sku:
+			return fmt.Errorf("product sku %s: %w", product.Sku, common.ErrExists)
+		case product.Barcode != "" && other.Barcode == product.Barcode:
+			return fmt.Errorf("product barcode %s: %w", product.Barcode, common.ErrExists)
+		case product.Slug != "" && other.Slug == product.Slug:
+			return fmt.Errorf("product slug %s: %w", product.Slug, common.ErrExists)
+		}
+	}
+
+	return nil
+}
+
+// live returns the product with productId unless it is missing or trashed.
+func (r *ProductRepository) live(productId int) (model.Product, error) {
+	product, ok := r.products[productId]
+	if !ok || product.DeletedAt.Valid {
+		return model.Product{}, fmt.Errorf("product %d: %w", productId, common.ErrNotFound)
+	}
+
+	return product, nil
+}
+
+// gallery returns the live images of productId, primary first.
+func (r *ProductRepository) gallery(productId int) []model.ProductImage {
+	images := []model.ProductImage{}
+	for _, image := range r.images {
+		if image.ProductId == productId && !image.DeletedAt.Valid {
+			images = append(images, image)
+		}
+	}
+
+	sort.Slice(images, func(i, j int) bool {
+		a, b := images[i], images[j]
+		if (a.IsPrimary == "yes") != (b.IsPrimary == "yes") {
+			return a.IsPrimary == "yes"
+		}
+		if a.Position != b.Position {
+			return a.Position < b.Position
+		}
+		return a.Id < b.Id
+	})
+
+	return images
+}
+
+// primaryImages returns the live primary images of productId.
+func (r *ProductRepository) primaryImages(productId int) []model.ProductImage {
+	images := []model.ProductImage{}
+	for _, image := range r.gallery(productId) {
+		if image.IsPrimary == "yes" {
+			images = append(images, image)
+		}
+	}
+
+	return images
+}
+
+// sorted returns the products matching keep ordered by id.
+func (r *ProductRepository) sorted(keep func(model.Product) bool) []model.Product {
+	products := []model.Product{}
+	for _, product := range r.products {
+		if keep(product) {
+			products = append(products, product)
+		}
+	}
+
+	sort.Slice(products, func(i, j int) bool { return products[i].Id < products[j].Id })
+	return products
+}
+
+// CreateProduct implements repository.ProductRepository
+func (r *ProductRepository) CreateProduct(ctx context.Context, product model.Product, stock model.InventoryMovement) (model.Product, model.InventoryMovement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product.Id = 0
+	err := r.checkUnique(product)
+	if err != nil {
+		return model.Product{}, model.InventoryMovement{}, err
+	}
+
+	now := time.Now()
+	product.CreatedAt, product.UpdatedAt = now, now
+
+	quantity := product.Quantity
+	product.Quantity = 0
+	product = r.put(product)
+
+	movement := r.setStock(&product, quantity, stock)
+	return product, movement, nil
+}
+
+// FindProductById implements repository.ProductRepository
+func (r *ProductRepository) FindProductById(ctx context.Context, productId int) (model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.live(productId)
+}
+
+// UpdateProduct implements repository.ProductRepository. Like the database
+// repository it keeps the low-stock alert and books a changed quantity as
+// stock and a changed slug in the history, after the update has passed its
+// checks.
+func (r *ProductRepository) UpdateProduct(ctx context.Context, product model.Product, stock model.InventoryMovement) (model.Product, model.InventoryMovement, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.live(product.Id)
+	if err != nil {
+		return model.Product{}, model.InventoryMovement{}, err
+	}
+
+	err = r.checkUnique(product)
+	if err != nil {
+		return model.Product{}, model.InventoryMovement{}, err
+	}
+
+	quantity := product.Quantity
+	product.Quantity = stored.Quantity
+	product.LowStockAlertedAt = stored.LowStockAlertedAt
+	product.UpdatedAt = time.Now()
+
+	movement := r.setStock(&product, quantity, stock)
+	r.put(product)
+
+	if product.Slug != stored.Slug {
+		r.saveSlugHistory(product.Id, stored.Slug, product.Slug)
+	}
+
+	return product, movement, nil
+}
+
+// setStock books the change from product's quantity to quantity as stock
+// and returns the movement, or a zero movement when nothing changed.
+func (r *ProductRepository) setStock(product *model.Product, quantity int, stock model.InventoryMovement) model.InventoryMovement {
+	if quantity == product.Quantity {
+		return model.InventoryMovement{}
+	}
+
+	r.nextMovementId++
+	stock.Id = r.nextMovementId
+	stock.ProductId = product.Id
+	stock.Quantity = quantity - product.Quantity
+	stock.BalanceAfter = quantity
+	stock.CreatedAt = time.Now()
+	r.movements = append(r.movements, stock)
+
+	product.Quantity = quantity
+	r.products[product.Id] = *product
+
+	return stock
+}
+
+// Movements returns the stock booked for productId by CreateProduct and
+// UpdateProduct, oldest first.
+func (r *ProductRepository) Movements(productId int) []model.InventoryMovement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	movements := []model.InventoryMovement{}
+	for _, movement := range r.movements {
+		if movement.ProductId == productId {
+			movements = append(movements, movement)
+		}
+	}
+
+	return movements
+}
+
+// DeleteProduct implements repository.ProductRepository
+func (r *ProductRepository) DeleteProduct(ctx context.Context, productId int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, err := r.live(productId)
+	if err != nil {
+		return err
+	}
+
+	deletedAt := gorm.DeletedAt{Time: time.Now(), Valid: true}
+	for id, image := range r.images {
+		if image.ProductId == productId && !image.DeletedAt.Valid {
+			image.DeletedAt = deletedAt
+			r.images[id] = image
+		}
+	}
+
+	product.DeletedAt = deletedAt
+	r.products[productId] = product
+
+	return nil
+}
+
+// FindProductBySlug implements repository.ProductRepository
+func (r *ProductRepository) FindProductBySlug(ctx context.Context, slug string) (model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, product := range r.products {
+		if product.Slug == slug && !product.DeletedAt.Valid {
+			product.ProductImages = r.gallery(product.Id)
+			return product, nil
+		}
+	}
+
+	return model.Product{}, fmt.Errorf("product %s: %w", slug, common.ErrNotFound)
+}
+
+// FindProductBySku implements repository.ProductRepository
+func (r *ProductRepository) FindProductBySku(ctx context.Context, sku string) (model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, product := range r.products {
+		if product.Sku == sku {
+			return product, nil
+		}
+	}
+
+	return model.Product{}, fmt.Errorf("product sku %s: %w", sku, common.ErrNotFound)
+}
+
+// FindProductByBarcode implements repository.ProductRepository
+func (r *ProductRepository) FindProductByBarcode(ctx context.Context, barcode string) (model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, product := range r.products {
+		if product.Barcode == barcode {
+			return product, nil
+		}
+	}
+
+	return model.Product{}, fmt.Errorf("product barcode %s: %w", barcode, common.ErrNotFound)
+}
+
+// FindProductDetailById implements repository.ProductRepository
+func (r *ProductRepository) FindProductDetailById(ctx context.Context, productId int) (model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, err := r.live(productId)
+	if err != nil {
+		return model.Product{}, err
+	}
+
+	product.ProductImages = r.gallery(productId)
+	return product, nil
+}
+
+// FindRelatedProducts implements repository.ProductRepository
+func (r *ProductRepository) FindRelatedProducts(ctx context.Context, product model.Product, limit int) ([]model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	related := r.sorted(func(other model.Product) bool {
+		return other.Id != product.Id && !other.DeletedAt.Valid && other.Quantity > 0 &&
+			other.Price.Currency == product.Price.Currency
+	})
+
+	distance := func(other model.Product) int64 {
+		d := other.Price.Amount - product.Price.Amount
+		if d < 0 {
+			return -d
+		}
+		return d
+	}
+	sort.SliceStable(related, func(i, j int) bool { return distance(related[i]) < distance(related[j]) })
+
+	if len(related) > limit {
+		related = related[:limit]
+	}
+
+	for i := range related {
+		related[i].ProductImages = r.primaryImages(related[i].Id)
+	}
+
+	return related, nil
+}
+
+// FindSlugHistory implements repository.ProductRepository
+func (r *ProductRepository) FindSlugHistory(ctx context.Context, slug string) (model.ProductSlug, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, productSlug := range r.slugs {
+		if productSlug.Slug == slug {
+			return productSlug, nil
+		}
+	}
+
+	return model.ProductSlug{}, fmt.Errorf("product slug %s: %w", slug, common.ErrNotFound)
+}
+
+// IsSlugTaken implements repository.ProductRepository
+func (r *ProductRepository) IsSlugTaken(ctx context.Context, slug string, productId int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, product := range r.products {
+		if product.Slug == slug && product.Id != productId {
+			return true, nil
+		}
+	}
+
+	for _, productSlug := range r.slugs {
+		if productSlug.Slug == slug && productSlug.ProductId != productId {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// saveSlugHistory records a slug change the way the database repository
+// does.
+func (r *ProductRepository) saveSlugHistory(productId int, oldSlug string, newSlug string) {
+	slugs := []model.ProductSlug{}
+	for _, productSlug := range r.slugs {
+		if productSlug.ProductId != productId || productSlug.Slug != newSlug {
+			slugs = append(slugs, productSlug)
+		}
+	}
+	r.slugs = slugs
+
+	if oldSlug == "" {
+		return
+	}
+
+	for _, productSlug := range r.slugs {
+		if productSlug.Slug == oldSlug {
+			return
+		}
+	}
+
+	r.slugs = append(r.slugs, model.ProductSlug{
+		Id:        len(r.slugs) + 1,
+		ProductId: productId,
+		Slug:      oldSlug,
+		CreatedAt: time.Now(),
+	})
+}
+
+// FindTrashedProducts implements repository.ProductRepository
+func (r *ProductRepository) FindTrashedProducts(ctx context.Context) ([]model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	products := r.sorted(func(product model.Product) bool { return product.DeletedAt.Valid })
+	sort.SliceStable(products, func(i, j int) bool {
+		return products[i].DeletedAt.Time.After(products[j].DeletedAt.Time)
+	})
+
+	return products, nil
+}
+
+// RestoreProduct implements repository.ProductRepository
+func (r *ProductRepository) RestoreProduct(ctx context.Context, productId int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product, ok := r.products[productId]
+	if !ok || !product.DeletedAt.Valid {
+		return fmt.Errorf("product %d: %w", productId, common.ErrNotFound)
+	}
+
+	for id, image := range r.images {
+		if image.ProductId == productId && image.DeletedAt == product.DeletedAt {
+			image.DeletedAt = gorm.DeletedAt{}
+			r.images[id] = image
+		}
+	}
+
+	product.DeletedAt = gorm.DeletedAt{}
+	r.products[productId] = product
+
+	return nil
+}
+
+// PurgeTrashedProducts implements repository.ProductRepository
+func (r *ProductRepository) PurgeTrashedProducts(ctx context.Context, deletedBefore time.Time) (int64, []string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := map[int]bool{}
+	for id, product := range r.products {
+		if product.DeletedAt.Valid && product.DeletedAt.Time.Before(deletedBefore) {
+			purged[id] = true
+		}
+	}
+
+	unusedFiles := []string{}
+	for id, image := range r.images {
+		expired := image.DeletedAt.Valid && image.DeletedAt.Time.Before(deletedBefore)
+		if !expired && !purged[image.ProductId] {
+			continue
+		}
+
+		delete(r.images, id)
+		if image.ContentHash == "" {
+			continue
+		}
+
+		imageFile, ok := r.imageFiles[image.ContentHash]
+		if !ok {
+			continue
+		}
+
+		imageFile.RefCount--
+		r.imageFiles[image.ContentHash] = imageFile
+		if imageFile.RefCount <= 0 {
+			delete(r.imageFiles, image.ContentHash)
+			unusedFiles = append(unusedFiles, imageFile.FileName)
+		}
+	}
+
+	for id := range purged {
+		delete(r.products, id)
+	}
+
+	slugs := []model.ProductSlug{}
+	for _, slug := range r.slugs {
+		if !purged[slug.ProductId] {
+			slugs = append(slugs, slug)
+		}
+	}
+	r.slugs = slugs
+
+	movements := []model.InventoryMovement{}
+	for _, movement := range r.movements {
+		if !purged[movement.ProductId] {
+			movements = append(movements, movement)
+		}
+	}
+	r.movements = movements
+
+	return int64(len(purged)), unusedFiles, nil
+}
+
+// FindAllProductImagesByProductId implements repository.ProductRepository
+func (r *ProductRepository) FindAllProductImagesByProductId(ctx context.Context, productId int) ([]model.ProductImage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.gallery(productId), nil
+}
+
+// FindProductImageById implements repository.ProductRepository
+func (r *ProductRepository) FindProductImageById(ctx context.Context, prodImgId int) (model.ProductImage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	image, ok := r.images[prodImgId]
+	if !ok || image.DeletedAt.Valid {
+		return model.ProductImage{}, fmt.Errorf("product image %d: %w", prodImgId, common.ErrNotFound)
+	}
+
+	return image, nil
+}
+
+// ReorderProductImages implements repository.ProductRepository
+func (r *ProductRepository) ReorderProductImages(ctx context.Context, productId int, prodImgIds []int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, prodImgId := range prodImgIds {
+		image, ok := r.images[prodImgId]
+		if ok && image.ProductId == productId && !image.DeletedAt.Valid {
+			image.Position = i + 1
+			r.images[prodImgId] = image
+		}
+	}
+
+	return nil
+}
+
+// CreateProductImages implements repository.ProductRepository
+func (r *ProductRepository) CreateProductImages(ctx context.Context, productImages model.ProductImage, imageFile model.ImageFile) (model.ProductImage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if productImages.IsPrimary == "yes" {
+		for id, image := range r.images {
+			if image.ProductId == productImages.ProductId {
+				image.IsPrimary = "no"
+				r.images[id] = image
+			}
+		}
+	}
+
+	stored, ok := r.imageFiles[imageFile.Hash]
+	if ok {
+		stored.RefCount++
+		imageFile = stored
+	} else {
+		imageFile.RefCount = 1
+	}
+	r.imageFiles[imageFile.Hash] = imageFile
+
+	now := time.Now()
+	productImages.CreatedAt, productImages.UpdatedAt = now, now
+
+	return r.putImage(productImages), nil
+}
+
+// MarkAllProductImagesNonPrimary implements repository.ProductRepository
+func (r *ProductRepository) MarkAllProductImagesNonPrimary(ctx context.Context, productId int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, image := range r.images {
+		if image.ProductId == productId && !image.DeletedAt.Valid {
+			image.IsPrimary = "no"
+			r.images[id] = image
+		}
+	}
+
+	return true, nil
+}
+
+// DeleteProductImageById implements repository.ProductRepository
+func (r *ProductRepository) DeleteProductImageById(ctx context.Context, prodImgId int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	image, ok := r.images[prodImgId]
+	if !ok || image.DeletedAt.Valid {
+		return fmt.Errorf("product image %d: %w", prodImgId, common.ErrNotFound)
+	}
+
+	image.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.images[prodImgId] = image
+
+	return nil
+}
+
+// UpdateProductImageById implements repository.ProductRepository
+func (r *ProductRepository) UpdateProductImageById(ctx context.Context, productImage model.ProductImage) (model.ProductImage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	productImage.UpdatedAt = time.Now()
+	return r.putImage(productImage), nil
+}
+
+// FindAllProduct implements repository.ProductRepository
+func (r *ProductRepository) FindAllProduct(ctx context.Context) ([]model.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	products := r.sorted(func(product model.Product) bool { return !product.DeletedAt.Valid })
+	for i := range products {
+		products[i].ProductImages = r.primaryImages(products[i].Id)
+	}
+
+	return products, nil
+}
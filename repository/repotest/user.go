@@ -0,0 +1,127 @@
+// Package repotest provides in-memory repositories and model factories for
+// tests. The fakes follow the database repositories' contracts: a missing
+// row is common.ErrNotFound and a duplicate unique value common.ErrExists.
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"learn/common"
+	"learn/model"
+	"learn/repository"
+	"sync"
+	"time"
+)
+
+// UserRepository is an in-memory repository.UserRepository. Username and
+// email are unique.
+type UserRepository struct {
+	mu     sync.Mutex
+	users  map[int]model.User
+	nextId int
+}
+
+var _ repository.UserRepository = (*UserRepository)(nil)
+
+// NewUserRepository returns a UserRepository holding users.
+func NewUserRepository(users ...model.User) *UserRepository {
+	r := &UserRepository{users: map[int]model.User{}}
+	for _, user := range users {
+		r.put(user)
+	}
+
+	return r
+}
+
+func (r *UserRepository) put(user model.User) model.User {
+	if user.Id == 0 {
+		r.nextId++
+		user.Id = r.nextId
+	} else if user.Id > r.nextId {
+		r.nextId = user.Id
+	}
+
+	r.users[user.Id] = user
+	return user
+}
+
+// CreateUser implements repository.UserRepository
+func (r *UserRepository) CreateUser(ctx context.Context, user model.User) (model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Username == user.Username || existing.Email == user.Email {
+			return model.User{}, fmt.Errorf("user %s: %w", user.Username, common.ErrExists)
+		}
+	}
+
+	now := time.Now()
+	user.CreatedAt, user.UpdatedAt = now, now
+
+	return r.put(user), nil
+}
+
+// FindByID implements repository.UserRepository
+func (r *UserRepository) FindByID(ctx context.Context, id int) (model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return model.User{}, fmt.Errorf("user %d: %w", id, common.ErrNotFound)
+	}
+
+	return user, nil
+}
+
+// FindByEmail implements repository.UserRepository
+func (r *UserRepository) FindByEmail(ctx context.Context, email string) (model.User, error) {
+	return r.find(email, func(user model.User) bool { return user.Email == email })
+}
+
+// FindByUsername implements repository.UserRepository
+func (r *UserRepository) FindByUsername(ctx context.Context, username string) (model.User, error) {
+	return r.find(username, func(user model.User) bool { return user.Username == username })
+}
+
+func (r *UserRepository) find(key string, match func(model.User) bool) (model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range r.users {
+		if match(user) {
+			return user, nil
+		}
+	}
+
+	return model.User{}, fmt.Errorf("user %s: %w", key, common.ErrNotFound)
+}
+
+// FindByRole implements repository.UserRepository
+func (r *UserRepository) FindByRole(ctx context.Context, role string) ([]model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	users := []model.User{}
+	for id := 1; id <= r.nextId; id++ {
+		if user, ok := r.users[id]; ok && user.Role == role {
+			users = append(users, user)
+		}
+	}
+
+	return users, nil
+}
+
+// SaveNewPassword implements repository.UserRepository
+func (r *UserRepository) SaveNewPassword(ctx context.Context, user model.User) (model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.Id]; !ok {
+		return model.User{}, fmt.Errorf("user %d: %w", user.Id, common.ErrNotFound)
+	}
+
+	user.UpdatedAt = time.Now()
+	return r.put(user), nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"fmt"
+	"learn/model"
+
+	"gorm.io/gorm"
+)
+
+type TaxRepository interface {
+	CreateTaxClass(taxClass model.TaxClass) (model.TaxClass, error)
+	FindAllTaxClass() ([]model.TaxClass, error)
+	FindTaxClassById(taxClassId int) (model.TaxClass, error)
+	UpdateTaxClass(taxClass model.TaxClass) (model.TaxClass, error)
+}
+
+type taxRepository struct {
+	DB *gorm.DB
+}
+
+func NewTaxRepository(db *gorm.DB) TaxRepository {
+	return &taxRepository{
+		DB: db,
+	}
+}
+
+var (
+	emptyTaxClass   = model.TaxClass{}
+	emptyTaxClasses = []model.TaxClass{}
+)
+
+// CreateTaxClass implements TaxRepository
+func (r *taxRepository) CreateTaxClass(taxClass model.TaxClass) (model.TaxClass, error) {
+	err := r.DB.Create(&taxClass).Error
+	if err != nil {
+		return emptyTaxClass, fmt.Errorf("tax class: %w", translateError(err))
+	}
+
+	return taxClass, nil
+}
+
+// FindAllTaxClass implements TaxRepository
+func (r *taxRepository) FindAllTaxClass() ([]model.TaxClass, error) {
+	taxClasses := []model.TaxClass{}
+
+	err := r.DB.Order("id").Find(&taxClasses).Error
+	if err != nil {
+		return emptyTaxClasses, fmt.Errorf("tax class: %w", translateError(err))
+	}
+
+	return taxClasses, nil
+}
+
+// FindTaxClassById implements TaxRepository
+func (r *taxRepository) FindTaxClassById(taxClassId int) (model.TaxClass, error) {
+	taxClass := model.TaxClass{}
+
+	err := r.DB.Where("id = ?", taxClassId).First(&taxClass).Error
+	if err != nil {
+		return emptyTaxClass, fmt.Errorf("tax class %d: %w", taxClassId, err)
+	}
+
+	return taxClass, nil
+}
+
+// UpdateTaxClass implements TaxRepository
+func (r *taxRepository) UpdateTaxClass(taxClass model.TaxClass) (model.TaxClass, error) {
+	err := r.DB.Save(&taxClass).Error
+	if err != nil {
+		return emptyTaxClass, fmt.Errorf("tax class %d: %w", taxClass.Id, translateError(err))
+	}
+
+	return taxClass, nil
+}
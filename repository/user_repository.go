@@ -1,20 +1,20 @@
 package repository
 
 import (
-	"errors"
+	"context"
 	"fmt"
-	"learn/common"
 	"learn/model"
 
 	"gorm.io/gorm"
 )
 
 type UserRepository interface {
-	CreateUser(user model.User) (model.User, error)
-	FindByID(id int) (model.User, error)
-	FindByEmail(email string) (model.User, error)
-	FindByUsername(username string) (model.User, error)
-	SaveNewPassword(user model.User) (model.User, error)
+	CreateUser(ctx context.Context, user model.User) (model.User, error)
+	FindByID(ctx context.Context, id int) (model.User, error)
+	FindByEmail(ctx context.Context, email string) (model.User, error)
+	FindByUsername(ctx context.Context, username string) (model.User, error)
+	FindByRole(ctx context.Context, role string) ([]model.User, error)
+	SaveNewPassword(ctx context.Context, user model.User) (model.User, error)
 }
 
 type userRepository struct {
@@ -28,74 +28,73 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 }
 
 var (
-	emptyUser = model.User{}
+	emptyUser  = model.User{}
+	emptyUsers = []model.User{}
 )
 
 // CreateUser implements UserRepository
-func (r *userRepository) CreateUser(user model.User) (model.User, error) {
-	err := r.DB.Create(&user).Error
+func (r *userRepository) CreateUser(ctx context.Context, user model.User) (model.User, error) {
+	err := r.DB.WithContext(ctx).Create(&user).Error
 	if err != nil {
-		if errors.Is(err, common.ErrFailedCreateData) {
-			return emptyUser, fmt.Errorf("user: %w", common.ErrFailedCreateData)
-		}
+		return emptyUser, fmt.Errorf("user %s: %w", user.Username, translateError(err))
 	}
 
 	return user, nil
 }
 
 // FindByID implements UserRepository
-func (r *userRepository) FindByID(id int) (model.User, error) {
+func (r *userRepository) FindByID(ctx context.Context, id int) (model.User, error) {
 	dbUser := model.User{}
 
-	if id == 0 {
-		return emptyUser, fmt.Errorf("user %d: %w", id, common.ErrNotFound)
-	}
-
-	err := r.DB.Where("id = ?", id).Find(&dbUser).Error
+	err := r.DB.WithContext(ctx).Where("id = ?", id).First(&dbUser).Error
 	if err != nil {
-		if errors.Is(err, common.ErrNotFound) {
-			return emptyUser, fmt.Errorf("user %d: %w", id, common.ErrNotFound)
-		}
+		return emptyUser, fmt.Errorf("user %d: %w", id, translateError(err))
 	}
 
 	return dbUser, nil
 }
 
 // FindByEmail implements UserRepository
-func (r *userRepository) FindByEmail(email string) (model.User, error) {
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (model.User, error) {
 	dbUser := model.User{}
 
-	err := r.DB.Where("email = ?", email).Find(&dbUser).Error
+	err := r.DB.WithContext(ctx).Where("email = ?", email).First(&dbUser).Error
 	if err != nil {
-		if errors.Is(err, common.ErrNotFound) {
-			return emptyUser, fmt.Errorf("user %s: %w", email, common.ErrNotFound)
-		}
+		return emptyUser, fmt.Errorf("user %s: %w", email, translateError(err))
 	}
 
 	return dbUser, nil
 }
 
 // FindByUsername implements UserRepository
-func (r *userRepository) FindByUsername(username string) (model.User, error) {
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (model.User, error) {
 	dbUser := model.User{}
 
-	err := r.DB.Where("username = ?", username).Find(&dbUser).Error
+	err := r.DB.WithContext(ctx).Where("username = ?", username).First(&dbUser).Error
 	if err != nil {
-		if errors.Is(err, common.ErrNotFound) {
-			return emptyUser, fmt.Errorf("user %s: %w", username, common.ErrNotFound)
-		}
+		return emptyUser, fmt.Errorf("user %s: %w", username, translateError(err))
 	}
 
 	return dbUser, nil
 }
 
+// FindByRole implements UserRepository
+func (r *userRepository) FindByRole(ctx context.Context, role string) ([]model.User, error) {
+	users := []model.User{}
+
+	err := r.DB.WithContext(ctx).Where("role = ?", role).Find(&users).Error
+	if err != nil {
+		return emptyUsers, fmt.Errorf("user role %s: %w", role, err)
+	}
+
+	return users, nil
+}
+
 // SaveNewPassword implements UserRepository
-func (r *userRepository) SaveNewPassword(user model.User) (model.User, error) {
-	err := r.DB.Save(&user).Error
+func (r *userRepository) SaveNewPassword(ctx context.Context, user model.User) (model.User, error) {
+	err := r.DB.WithContext(ctx).Save(&user).Error
 	if err != nil {
-		if errors.Is(err, common.ErrFailedUpdateData) {
-			return emptyUser, fmt.Errorf("user : %w", common.ErrFailedUpdateData)
-		}
+		return emptyUser, fmt.Errorf("user %d: %w", user.Id, translateError(err))
 	}
 
 	return user, nil
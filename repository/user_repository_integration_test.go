@@ -0,0 +1,115 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"learn/common"
+	"learn/model"
+	"learn/repository"
+	"learn/repository/repotest"
+	"testing"
+)
+
+func TestUserRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewUserRepository(newDB(t))
+
+	user, err := repo.CreateUser(ctx, repotest.NewUser())
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if user.Id == 0 {
+		t.Fatalf("CreateUser returned no id")
+	}
+
+	found, err := repo.FindByUsername(ctx, user.Username)
+	if err != nil || found.Id != user.Id {
+		t.Errorf("FindByUsername = %d, %v, want %d", found.Id, err, user.Id)
+	}
+
+	found, err = repo.FindByEmail(ctx, user.Email)
+	if err != nil || found.Id != user.Id {
+		t.Errorf("FindByEmail = %d, %v, want %d", found.Id, err, user.Id)
+	}
+
+	_, err = repo.FindByID(ctx, user.Id+1)
+	if !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("FindByID of missing user error = %v, want ErrNotFound", err)
+	}
+
+	_, err = repo.CreateUser(ctx, repotest.NewAdmin())
+	if err != nil {
+		t.Fatalf("CreateUser admin: %v", err)
+	}
+
+	admins, err := repo.FindByRole(ctx, "admin")
+	if err != nil || len(admins) != 1 {
+		t.Errorf("FindByRole admin = %d users, %v, want 1", len(admins), err)
+	}
+}
+
+func TestUserRepositoryCanceledContext(t *testing.T) {
+	repo := repository.NewUserRepository(newDB(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.CreateUser(ctx, repotest.NewUser())
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("CreateUser with canceled context error = %v, want context.Canceled", err)
+	}
+}
+
+func TestAddressRepository(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	users := repository.NewUserRepository(db)
+	repo := repository.NewAddressRepository(db)
+
+	user, err := users.CreateUser(ctx, repotest.NewUser())
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	primary, err := repo.Create(ctx, repotest.NewAddress(user.Id))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	_, err = repo.Create(ctx, repotest.NewAddress(user.Id, func(a *model.Address) { a.IsPrimary = "no" }))
+	if err != nil {
+		t.Fatalf("Create second: %v", err)
+	}
+
+	_, err = repo.MarkAllAddressNonPrimary(ctx, user.Id)
+	if err != nil {
+		t.Fatalf("MarkAllAddressNonPrimary: %v", err)
+	}
+
+	addresses, err := repo.FindByUserId(ctx, user.Id)
+	if err != nil || len(addresses) != 2 {
+		t.Fatalf("FindByUserId = %d addresses, %v, want 2", len(addresses), err)
+	}
+	for _, address := range addresses {
+		if address.IsPrimary != "no" {
+			t.Errorf("address %d IsPrimary = %q, want no", address.Id, address.IsPrimary)
+		}
+	}
+
+	err = repo.Delete(ctx, primary.Id)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	err = repo.Delete(ctx, primary.Id)
+	if !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("Delete twice error = %v, want ErrNotFound", err)
+	}
+
+	_, err = repo.FindByAddressId(ctx, primary.Id)
+	if !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("FindByAddressId of deleted address error = %v, want ErrNotFound", err)
+	}
+}
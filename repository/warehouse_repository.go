@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"learn/model"
+
+	"gorm.io/gorm"
+)
+
+type WarehouseRepository interface {
+	// Warehouse
+	CreateWarehouse(ctx context.Context, warehouse model.Warehouse) (model.Warehouse, error)
+	FindAllWarehouse(ctx context.Context) ([]model.Warehouse, error)
+	FindWarehouseById(ctx context.Context, warehouseId int) (model.Warehouse, error)
+	UpdateWarehouse(ctx context.Context, warehouse model.Warehouse) (model.Warehouse, error)
+	// Stock
+	FindStocksByWarehouseId(ctx context.Context, warehouseId int) ([]model.WarehouseStock, error)
+	FindStocksByProductId(ctx context.Context, productId int) ([]model.WarehouseStock, error)
+	TransferStock(ctx context.Context, transfer model.StockTransfer) (model.StockTransfer, error)
+	AllocateStock(ctx context.Context, movement model.InventoryMovement) (model.InventoryMovement, error)
+}
+
+type warehouseRepository struct {
+	DB *gorm.DB
+}
+
+func NewWarehouseRepository(db *gorm.DB) WarehouseRepository {
+	return &warehouseRepository{
+		DB: db,
+	}
+}
+
+var (
+	emptyWarehouse       = model.Warehouse{}
+	emptyWarehouses      = []model.Warehouse{}
+	emptyWarehouseStocks = []model.WarehouseStock{}
+	emptyStockTransfer   = model.StockTransfer{}
+)
+
+// CreateWarehouse implements WarehouseRepository
+func (r *warehouseRepository) CreateWarehouse(ctx context.Context, warehouse model.Warehouse) (model.Warehouse, error) {
+	err := r.DB.WithContext(ctx).Create(&warehouse).Error
+	if err != nil {
+		return emptyWarehouse, fmt.Errorf("warehouse: %w", translateError(err))
+	}
+
+	return warehouse, nil
+}
+
+// FindAllWarehouse implements WarehouseRepository
+func (r *warehouseRepository) FindAllWarehouse(ctx context.Context) ([]model.Warehouse, error) {
+	warehouses := []model.Warehouse{}
+
+	err := r.DB.WithContext(ctx).Order("id").Find(&warehouses).Error
+	if err != nil {
+		return emptyWarehouses, fmt.Errorf("warehouse: %w", translateError(err))
+	}
+
+	return warehouses, nil
+}
+
+// FindWarehouseById implements WarehouseRepository
+func (r *warehouseRepository) FindWarehouseById(ctx context.Context, warehouseId int) (model.Warehouse, error) {
+	warehouse := model.Warehouse{}
+
+	err := r.DB.WithContext(ctx).Where("id = ?", warehouseId).First(&warehouse).Error
+	if err != nil {
+		return emptyWarehouse, fmt.Errorf("warehouse %d: %w", warehouseId, err)
+	}
+
+	return warehouse, nil
+}
+
+// UpdateWarehouse implements WarehouseRepository
+func (r *warehouseRepository) UpdateWarehouse(ctx context.Context, warehouse model.Warehouse) (model.Warehouse, error) {
+	err := r.DB.WithContext(ctx).Save(&warehouse).Error
+	if err != nil {
+		return emptyWarehouse, fmt.Errorf("warehouse %d: %w", warehouse.Id, err)
+	}
+
+	return warehouse, nil
+}
+
+// FindStocksByWarehouseId implements WarehouseRepository
+func (r *warehouseRepository) FindStocksByWarehouseId(ctx context.Context, warehouseId int) ([]model.WarehouseStock, error) {
+	stocks := []model.WarehouseStock{}
+
+	err := r.DB.WithContext(ctx).Where("warehouse_id = ?", warehouseId).Order("product_id").Find(&stocks).Error
+	if err != nil {
+		return emptyWarehouseStocks, fmt.Errorf("warehouse stock %d: %w", warehouseId, err)
+	}
+
+	return stocks, nil
+}
+
+// FindStocksByProductId implements WarehouseRepository
+func (r *warehouseRepository) FindStocksByProductId(ctx context.Context, productId int) ([]model.WarehouseStock, error) {
+	stocks := []model.WarehouseStock{}
+
+	err := r.DB.WithContext(ctx).Preload("Warehouse").Where("product_id = ?", productId).Find(&stocks).Error
+	if err != nil {
+		return emptyWarehouseStocks, fmt.Errorf("warehouse stock product %d: %w", productId, err)
+	}
+
+	return stocks, nil
+}
+
+// TransferStock implements WarehouseRepository. Both locations change in
+// one transaction under the product's lock; the product total stays the
+// same.
+func (r *warehouseRepository) TransferStock(ctx context.Context, transfer model.StockTransfer) (model.StockTransfer, error) {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		_, err := lockProduct(tx, transfer.ProductId)
+		if err != nil {
+			return err
+		}
+
+		err = adjustWarehouseStock(tx, transfer.FromWarehouseId, transfer.ProductId, -transfer.Quantity)
+		if err != nil {
+			return err
+		}
+
+		err = adjustWarehouseStock(tx, transfer.ToWarehouseId, transfer.ProductId, transfer.Quantity)
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&transfer).Error
+	})
+	if err != nil {
+		return emptyStockTransfer, fmt.Errorf("stock transfer: %w", translateError(err))
+	}
+
+	return transfer, nil
+}
+
+// AllocateStock implements WarehouseRepository. The allocated quantity
+// leaves the warehouse and the product total together, booked in the
+// inventory ledger as movement. It fails with ErrInsufficientStock when the
+// warehouse no longer holds enough once its row is locked.
+func (r *warehouseRepository) AllocateStock(ctx context.Context, movement model.InventoryMovement) (model.InventoryMovement, error) {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		movement, err = bookMovement(tx, movement)
+		return err
+	})
+	if err != nil {
+		return emptyInventoryMovement, fmt.Errorf("stock allocation: %w", translateError(err))
+	}
+
+	return movement, nil
+}
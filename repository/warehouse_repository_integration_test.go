@@ -0,0 +1,90 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"learn/common"
+	"learn/model"
+	"learn/repository"
+	"learn/repository/repotest"
+	"testing"
+)
+
+func TestWarehouseRepositoryAllocateStock(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	products := repository.NewProductRepository(db)
+	inventory := repository.NewInventoryRepository(db)
+	repo := repository.NewWarehouseRepository(db)
+
+	product, _, err := products.CreateProduct(ctx, repotest.NewProduct(func(p *model.Product) { p.Quantity = 10 }), openingStock)
+	if err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+	warehouse, err := repo.CreateWarehouse(ctx, model.Warehouse{Code: "JKT", Name: "Jakarta", Address: "Jl. Gudang 1"})
+	if err != nil {
+		t.Fatalf("CreateWarehouse: %v", err)
+	}
+
+	_, err = inventory.RecordMovement(model.InventoryMovement{ProductId: product.Id, WarehouseId: warehouse.Id, Type: model.MovementRestock, Quantity: 4, UserId: 1})
+	if err != nil {
+		t.Fatalf("RecordMovement to warehouse: %v", err)
+	}
+
+	_, err = inventory.RecordMovement(model.InventoryMovement{ProductId: product.Id, WarehouseId: warehouse.Id + 1, Type: model.MovementRestock, Quantity: 4, UserId: 1})
+	if !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("RecordMovement to a missing warehouse error = %v, want ErrNotFound", err)
+	}
+
+	_, err = inventory.RecordMovement(model.InventoryMovement{ProductId: product.Id, Type: model.MovementSale, Quantity: -11, UserId: 1})
+	if !errors.Is(err, common.ErrInsufficientStock) {
+		t.Errorf("RecordMovement of stock held by a warehouse error = %v, want ErrInsufficientStock", err)
+	}
+
+	allocation := model.InventoryMovement{ProductId: product.Id, WarehouseId: warehouse.Id, Type: model.MovementSale, Quantity: -3, UserId: 1}
+	movement, err := repo.AllocateStock(ctx, allocation)
+	if err != nil {
+		t.Fatalf("AllocateStock: %v", err)
+	}
+	if movement.BalanceAfter != 11 {
+		t.Errorf("BalanceAfter = %d, want 11", movement.BalanceAfter)
+	}
+
+	stocks, err := repo.FindStocksByProductId(ctx, product.Id)
+	if err != nil || len(stocks) != 1 || stocks[0].Quantity != 1 {
+		t.Errorf("warehouse stocks = %+v, %v, want 1 left", stocks, err)
+	}
+
+	_, err = repo.AllocateStock(ctx, allocation)
+	if !errors.Is(err, common.ErrInsufficientStock) {
+		t.Errorf("AllocateStock beyond the warehouse's stock error = %v, want ErrInsufficientStock", err)
+	}
+}
+
+func TestWarehouseRepositoryErrors(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	repo := repository.NewWarehouseRepository(db)
+
+	jakarta, err := repo.CreateWarehouse(ctx, model.Warehouse{Code: "JKT", Name: "Jakarta", Address: "Jl. Gudang 1"})
+	if err != nil {
+		t.Fatalf("CreateWarehouse: %v", err)
+	}
+	bandung, err := repo.CreateWarehouse(ctx, model.Warehouse{Code: "BDG", Name: "Bandung", Address: "Jl. Gudang 2"})
+	if err != nil {
+		t.Fatalf("CreateWarehouse: %v", err)
+	}
+
+	_, err = repo.FindWarehouseById(ctx, bandung.Id+1)
+	if !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("FindWarehouseById of missing warehouse error = %v, want ErrNotFound", err)
+	}
+
+	bandung.Code = jakarta.Code
+	_, err = repo.UpdateWarehouse(ctx, bandung)
+	if !errors.Is(err, common.ErrExists) {
+		t.Errorf("UpdateWarehouse to a taken code error = %v, want ErrExists", err)
+	}
+}
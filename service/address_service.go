@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"learn/common"
 	"learn/model"
@@ -8,10 +9,11 @@ import (
 )
 
 type AddressService interface {
-	AddAddress(req model.AddressReq, userId int) (model.AddressRes, error)
-	GetAddresses(userId int) ([]model.AddressRes, error)
-	UpdateAddress(req model.AddressReq, addressId int) (model.AddressRes, error)
-	DeleteAddress(addressId int) (model.AddressResWithoutData, error)
+	AddAddress(ctx context.Context, req model.AddressReq, userId int) (model.AddressRes, error)
+	GetAddresses(ctx context.Context, userId int) ([]model.AddressRes, error)
+	FindAddressById(ctx context.Context, addressId int, userId int) (model.AddressRes, error)
+	UpdateAddress(ctx context.Context, req model.AddressReq, addressId int) (model.AddressRes, error)
+	DeleteAddress(ctx context.Context, addressId int, userId int) (model.AddressResWithoutData, error)
 }
 
 type serviceAddress struct {
@@ -31,21 +33,21 @@ var (
 )
 
 // CreateAddress implements AddressService
-func (s *serviceAddress) AddAddress(req model.AddressReq, userId int) (model.AddressRes, error) {
+func (s *serviceAddress) AddAddress(ctx context.Context, req model.AddressReq, userId int) (model.AddressRes, error) {
 	address := model.Address{}
 	isPrimary := "no"
 
-	arrayAddress, err := s.Repo.FindByUserId(userId)
+	arrayAddress, err := s.Repo.FindByUserId(ctx, userId)
 	if err != nil {
 		return emptyAddressRes, fmt.Errorf("FindByUserId call failed: %w", err)
 	}
 
 	if len(arrayAddress) == 0 && req.IsPrimary != true {
-		return emptyAddressRes, fmt.Errorf("address: %w", err)
+		return emptyAddressRes, fmt.Errorf("first address : %w", common.ErrMustHavePrimary)
 	} else if len(arrayAddress) >= 1 && req.IsPrimary {
 		isPrimary = "yes"
 
-		_, err := s.Repo.MarkAllAddressNonPrimary(userId)
+		_, err := s.Repo.MarkAllAddressNonPrimary(ctx, userId)
 		if err != nil {
 			return emptyAddressRes, fmt.Errorf("MarkAllAddressNonPrimary call failed: %w", err)
 		}
@@ -54,10 +56,13 @@ func (s *serviceAddress) AddAddress(req model.AddressReq, userId int) (model.Add
 	}
 
 	address.Address = req.Address
+	address.PostalCode = req.PostalCode
 	address.IsPrimary = isPrimary
+	address.Latitude = req.Latitude
+	address.Longitude = req.Longitude
 	address.UserId = req.UserId
 
-	addressDB, err := s.Repo.Create(address)
+	addressDB, err := s.Repo.Create(ctx, address)
 	if err != nil {
 		return emptyAddressRes, fmt.Errorf("create call failed: %w", err)
 	}
@@ -70,21 +75,25 @@ func (s *serviceAddress) AddAddress(req model.AddressReq, userId int) (model.Add
 	}
 
 	response := model.AddressRes{
-		Address:   addressDB.Address,
-		IsPrimary: resIsPrimary,
-		UserId:    addressDB.UserId,
+		Id:         addressDB.Id,
+		Address:    addressDB.Address,
+		PostalCode: addressDB.PostalCode,
+		IsPrimary:  resIsPrimary,
+		Latitude:   addressDB.Latitude,
+		Longitude:  addressDB.Longitude,
+		UserId:     addressDB.UserId,
 	}
 
 	return response, nil
 }
 
 // GetAddresses implements AddressService
-func (s *serviceAddress) GetAddresses(userId int) ([]model.AddressRes, error) {
+func (s *serviceAddress) GetAddresses(ctx context.Context, userId int) ([]model.AddressRes, error) {
 	formatAddresses := []model.AddressRes{}
 
-	arrayAddress, err := s.Repo.FindByUserId(userId)
+	arrayAddress, err := s.Repo.FindByUserId(ctx, userId)
 	if err != nil {
-		return emptyAddressesRes, fmt.Errorf("address user id %d : %w", userId, common.ErrNotFound)
+		return emptyAddressesRes, fmt.Errorf("FindByUserId call failed: %w", err)
 	}
 
 	for _, addr := range arrayAddress {
@@ -97,9 +106,13 @@ func (s *serviceAddress) GetAddresses(userId int) ([]model.AddressRes, error) {
 		}
 
 		formatAddress := model.AddressRes{
-			Address:   addr.Address,
-			IsPrimary: resIsPrimary,
-			UserId:    addr.UserId,
+			Id:         addr.Id,
+			Address:    addr.Address,
+			PostalCode: addr.PostalCode,
+			IsPrimary:  resIsPrimary,
+			Latitude:   addr.Latitude,
+			Longitude:  addr.Longitude,
+			UserId:     addr.UserId,
 		}
 
 		formatAddresses = append(formatAddresses, formatAddress)
@@ -108,24 +121,44 @@ func (s *serviceAddress) GetAddresses(userId int) ([]model.AddressRes, error) {
 	return formatAddresses, nil
 }
 
+// FindAddressById implements AddressService
+func (s *serviceAddress) FindAddressById(ctx context.Context, addressId int, userId int) (model.AddressRes, error) {
+	address, err := s.Repo.FindByAddressId(ctx, addressId)
+	if err != nil {
+		return emptyAddressRes, fmt.Errorf("FindByAddressId call failed: %w", err)
+	}
+
+	if address.UserId != userId {
+		return emptyAddressRes, fmt.Errorf("address %d : %w", addressId, common.ErrNotFound)
+	}
+
+	response := model.AddressRes{
+		Id:         address.Id,
+		Address:    address.Address,
+		PostalCode: address.PostalCode,
+		IsPrimary:  address.IsPrimary == "yes",
+		Latitude:   address.Latitude,
+		Longitude:  address.Longitude,
+		UserId:     address.UserId,
+	}
+
+	return response, nil
+}
+
 // UpdateAddress implements AddressService
-func (s *serviceAddress) UpdateAddress(req model.AddressReq, addressId int) (model.AddressRes, error) {
+func (s *serviceAddress) UpdateAddress(ctx context.Context, req model.AddressReq, addressId int) (model.AddressRes, error) {
 	isPrimary := "no"
 
-	address, err := s.Repo.FindByAddressId(addressId)
+	address, err := s.Repo.FindByAddressId(ctx, addressId)
 	if err != nil {
 		return emptyAddressRes, fmt.Errorf("FindByAddressId call failed: %w", err)
 	}
 
-	if address.Id == 0 {
-		return emptyAddressRes, fmt.Errorf("address %d : %w", address.Id, common.ErrNotFound)
-	}
-
 	if req.UserId != address.UserId {
 		return emptyAddressRes, fmt.Errorf("address user %d : %w", req.UserId, common.ErrNotFound)
 	}
 
-	arrayAddress, err := s.Repo.FindByUserId(req.UserId)
+	arrayAddress, err := s.Repo.FindByUserId(ctx, req.UserId)
 	if err != nil {
 		return emptyAddressRes, fmt.Errorf("FindByUserId call failed: %w", err)
 	}
@@ -134,7 +167,7 @@ func (s *serviceAddress) UpdateAddress(req model.AddressReq, addressId int) (mod
 		if req.IsPrimary {
 			isPrimary = "yes"
 
-			_, err := s.Repo.MarkAllAddressNonPrimary(addrs.UserId)
+			_, err := s.Repo.MarkAllAddressNonPrimary(ctx, addrs.UserId)
 			if err != nil {
 				return emptyAddressRes, fmt.Errorf("MarkAllAddressNonPrimary call failed: %w", err)
 			} else {
@@ -142,15 +175,18 @@ func (s *serviceAddress) UpdateAddress(req model.AddressReq, addressId int) (mod
 			}
 		}
 		if req.IsPrimary == false && addrs.IsPrimary == "yes" {
-			return emptyAddressRes, fmt.Errorf("address: %w", err)
+			return emptyAddressRes, fmt.Errorf("address %d : %w", addrs.Id, common.ErrMustHavePrimary)
 		}
 	}
 
 	address.Address = req.Address
+	address.PostalCode = req.PostalCode
 	address.IsPrimary = isPrimary
+	address.Latitude = req.Latitude
+	address.Longitude = req.Longitude
 	address.UserId = req.UserId
 
-	updateAddress, err := s.Repo.Update(address)
+	updateAddress, err := s.Repo.Update(ctx, address)
 	if err != nil {
 		return emptyAddressRes, fmt.Errorf("update call failed: %w", err)
 	}
@@ -163,27 +199,35 @@ func (s *serviceAddress) UpdateAddress(req model.AddressReq, addressId int) (mod
 	}
 
 	response := model.AddressRes{
-		Address:   updateAddress.Address,
-		IsPrimary: resIsPrimary,
-		UserId:    updateAddress.UserId,
+		Id:         updateAddress.Id,
+		Address:    updateAddress.Address,
+		PostalCode: updateAddress.PostalCode,
+		IsPrimary:  resIsPrimary,
+		Latitude:   updateAddress.Latitude,
+		Longitude:  updateAddress.Longitude,
+		UserId:     updateAddress.UserId,
 	}
 
 	return response, nil
 }
 
 // DeleteAddress implements AddressService
-func (s *serviceAddress) DeleteAddress(addressId int) (model.AddressResWithoutData, error) {
-	address, err := s.Repo.FindByAddressId(addressId)
+func (s *serviceAddress) DeleteAddress(ctx context.Context, addressId int, userId int) (model.AddressResWithoutData, error) {
+	address, err := s.Repo.FindByAddressId(ctx, addressId)
 
 	if err != nil {
 		return emptyAddressWithoutData, fmt.Errorf("FindByAddressId call failed: %w", err)
 	}
 
+	if address.UserId != userId {
+		return emptyAddressWithoutData, fmt.Errorf("address user %d : %w", userId, common.ErrNotFound)
+	}
+
 	if address.IsPrimary == "yes" {
-		return emptyAddressWithoutData, fmt.Errorf("address : %w", common.ErrExists)
+		return emptyAddressWithoutData, fmt.Errorf("address %d : %w", addressId, common.ErrMustHavePrimary)
 	}
 
-	err = s.Repo.Delete(addressId)
+	err = s.Repo.Delete(ctx, addressId)
 	if err != nil {
 		return emptyAddressWithoutData, fmt.Errorf("delete call failed: %w", err)
 	}
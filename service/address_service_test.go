@@ -0,0 +1,104 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"learn/common"
+	"learn/model"
+	"learn/repository"
+	"learn/repository/repotest"
+	"learn/service"
+	"testing"
+)
+
+func newAddressService(addresses ...model.Address) (service.AddressService, *repotest.AddressRepository) {
+	fake := repotest.NewAddressRepository(addresses...)
+	var repo repository.AddressRepository = fake
+
+	return service.NewAddressService(&repo), fake
+}
+
+func TestAddAddress(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("first address must be primary", func(t *testing.T) {
+		srv, _ := newAddressService()
+
+		_, err := srv.AddAddress(ctx, model.AddressReq{Address: "Jl. Sudirman 1", UserId: 1}, 1)
+		if !errors.Is(err, common.ErrMustHavePrimary) {
+			t.Errorf("AddAddress error = %v, want ErrMustHavePrimary", err)
+		}
+	})
+
+	t.Run("new primary replaces the old one", func(t *testing.T) {
+		first := repotest.NewAddress(1, func(a *model.Address) { a.Id = 1 })
+		srv, repo := newAddressService(first)
+
+		res, err := srv.AddAddress(ctx, model.AddressReq{Address: "Jl. Sudirman 1", IsPrimary: true, UserId: 1}, 1)
+		if err != nil {
+			t.Fatalf("AddAddress: %v", err)
+		}
+		if !res.IsPrimary {
+			t.Errorf("new address IsPrimary = false, want true")
+		}
+
+		old, _ := repo.FindByAddressId(ctx, first.Id)
+		if old.IsPrimary != "no" {
+			t.Errorf("old address IsPrimary = %q, want no", old.IsPrimary)
+		}
+	})
+}
+
+func TestFindAddressById(t *testing.T) {
+	ctx := context.Background()
+	address := repotest.NewAddress(1, func(a *model.Address) { a.Id = 5 })
+	srv, _ := newAddressService(address)
+
+	res, err := srv.FindAddressById(ctx, address.Id, 1)
+	if err != nil {
+		t.Fatalf("FindAddressById: %v", err)
+	}
+	if res.Address != address.Address {
+		t.Errorf("FindAddressById address = %q, want %q", res.Address, address.Address)
+	}
+
+	_, err = srv.FindAddressById(ctx, address.Id, 2)
+	if !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("FindAddressById of another user error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDeleteAddress(t *testing.T) {
+	ctx := context.Background()
+	primary := repotest.NewAddress(1, func(a *model.Address) { a.Id = 1 })
+	other := repotest.NewAddress(1, func(a *model.Address) { a.Id = 2; a.IsPrimary = "no" })
+
+	tests := []struct {
+		name      string
+		addressId int
+		userId    int
+		wantErr   error
+	}{
+		{"primary", primary.Id, 1, common.ErrMustHavePrimary},
+		{"missing", 99, 1, common.ErrNotFound},
+		{"another user's", other.Id, 2, common.ErrNotFound},
+		{"secondary", other.Id, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, repo := newAddressService(primary, other)
+
+			_, err := srv.DeleteAddress(ctx, tt.addressId, tt.userId)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DeleteAddress error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil {
+				_, err = repo.FindByAddressId(ctx, tt.addressId)
+				if !errors.Is(err, common.ErrNotFound) {
+					t.Errorf("address %d still stored after delete", tt.addressId)
+				}
+			}
+		})
+	}
+}
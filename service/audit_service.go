@@ -0,0 +1,79 @@
+package service
+
+import (
+	"fmt"
+	"learn/model"
+	"learn/repository"
+)
+
+// Audit log page sizes
+const (
+	defaultAuditLogLimit = 50
+	maxAuditLogLimit     = 500
+)
+
+type AuditService interface {
+	// SYSTEM
+	Record(entry model.AuditEntry) error
+	// ADMIN
+	FindAuditLogs(filter model.AuditLogFilter) ([]model.AuditLogRes, error)
+}
+
+type auditService struct {
+	Repo repository.AuditRepository
+}
+
+func NewAuditService(repo repository.AuditRepository) AuditService {
+	return &auditService{
+		Repo: repo,
+	}
+}
+
+var (
+	emptyAuditLogsRes = []model.AuditLogRes{}
+)
+
+// Record implements AuditService
+func (s *auditService) Record(entry model.AuditEntry) error {
+	before, after, err := model.AuditDiff(entry.Before, entry.After)
+	if err != nil {
+		return fmt.Errorf("audit %s %s %d: %w", entry.Action, entry.EntityType, entry.EntityId, err)
+	}
+
+	log := model.AuditLog{
+		UserId:     entry.UserId,
+		Action:     entry.Action,
+		EntityType: entry.EntityType,
+		EntityId:   entry.EntityId,
+		Before:     before,
+		After:      after,
+		Ip:         entry.Ip,
+	}
+
+	_, err = s.Repo.CreateLog(log)
+	if err != nil {
+		return fmt.Errorf("CreateLog call failed: %w", err)
+	}
+
+	return nil
+}
+
+// FindAuditLogs implements AuditService
+func (s *auditService) FindAuditLogs(filter model.AuditLogFilter) ([]model.AuditLogRes, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLogLimit
+	}
+	if filter.Limit > maxAuditLogLimit {
+		filter.Limit = maxAuditLogLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	logs, err := s.Repo.FindLogs(filter)
+	if err != nil {
+		return emptyAuditLogsRes, fmt.Errorf("FindLogs call failed: %w", err)
+	}
+
+	return model.AuditLogsFormatRes(logs), nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/ean"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Barcode label layout in pixels, sized for 300 dpi label printers.
+const (
+	labelModuleWidth = 3
+	labelBarHeight   = 150
+	labelQuietZone   = 30
+	labelTextHeight  = 20
+	labelMaxNameLen  = 40
+)
+
+// renderBarcodeLabel draws a PNG label with the barcode, the human readable
+// code under it and the product name on top. An EAN/UPC barcode is used when
+// set, otherwise the SKU is encoded as Code 128.
+func renderBarcodeLabel(code string, sku string, name string) ([]byte, error) {
+	var symbol barcode.Barcode
+	var err error
+
+	switch {
+	case len(code) == 12:
+		// UPC-A is an EAN-13 with a leading zero.
+		symbol, err = ean.Encode("0" + code)
+	case code != "":
+		symbol, err = ean.Encode(code)
+	default:
+		code = sku
+		symbol, err = code128.Encode(sku)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	modules := symbol.Bounds().Dx()
+	symbol, err = barcode.Scale(symbol, modules*labelModuleWidth, labelBarHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	if runes := []rune(name); len(runes) > labelMaxNameLen {
+		name = string(runes[:labelMaxNameLen-3]) + "..."
+	}
+
+	width := symbol.Bounds().Dx() + 2*labelQuietZone
+	height := labelTextHeight + labelBarHeight + labelTextHeight + labelQuietZone
+	label := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(label, label.Bounds(), image.White, image.Point{}, draw.Src)
+
+	barsAt := image.Pt(labelQuietZone, labelTextHeight+labelQuietZone/2)
+	draw.Draw(label, symbol.Bounds().Add(barsAt), symbol, image.Point{}, draw.Src)
+
+	drawLabelText(label, name, labelQuietZone/2+labelTextHeight/2)
+	drawLabelText(label, code, barsAt.Y+labelBarHeight+labelTextHeight)
+
+	var buf bytes.Buffer
+	err = png.Encode(&buf, label)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// drawLabelText writes text centred horizontally with its baseline at y.
+func drawLabelText(label *image.RGBA, text string, y int) {
+	drawer := font.Drawer{
+		Dst:  label,
+		Src:  image.NewUniform(color.Black),
+		Face: basicfont.Face7x13,
+	}
+
+	x := (label.Bounds().Dx() - drawer.MeasureString(text).Round()) / 2
+	drawer.Dot = fixed.P(x, y)
+	drawer.DrawString(text)
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"learn/common"
+	"learn/model"
+	"learn/repository"
+	"strconv"
+	"strings"
+)
+
+type ExchangeRateService interface {
+	// ADMIN
+	SetExchangeRate(req model.ExchangeRateReq) (model.ExchangeRateRes, error)
+	ImportExchangeRates(file io.Reader) ([]model.ExchangeRateRes, error)
+	FindAllExchangeRate() ([]model.ExchangeRateRes, error)
+}
+
+type exchangeRateService struct {
+	Repo repository.ExchangeRateRepository
+}
+
+func NewExchangeRateService(repo repository.ExchangeRateRepository) ExchangeRateService {
+	return &exchangeRateService{
+		Repo: repo,
+	}
+}
+
+var (
+	emptyExchangeRateRes  = model.ExchangeRateRes{}
+	emptyExchangeRatesRes = []model.ExchangeRateRes{}
+)
+
+// SetExchangeRate implements ExchangeRateService
+func (s *exchangeRateService) SetExchangeRate(req model.ExchangeRateReq) (model.ExchangeRateRes, error) {
+	rate, err := newExchangeRate(req.Currency, req.Rate, "manual")
+	if err != nil {
+		return emptyExchangeRateRes, err
+	}
+
+	rates, err := s.Repo.SaveRates([]model.ExchangeRate{rate})
+	if err != nil {
+		return emptyExchangeRateRes, fmt.Errorf("SaveRates call failed: %w", err)
+	}
+
+	return model.ExchangeRateFormatRes(rates[0]), nil
+}
+
+// ImportExchangeRates implements ExchangeRateService. The file is a CSV of
+// currency,rate rows with an optional header; any invalid row rejects the
+// whole file, and so does a currency listed twice.
+func (s *exchangeRateService) ImportExchangeRates(file io.Reader) ([]model.ExchangeRateRes, error) {
+	rates := []model.ExchangeRate{}
+	lines := map[string]int{}
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return emptyExchangeRatesRes, fmt.Errorf("exchange rate file: %w", err)
+		}
+
+		if line == 1 && strings.EqualFold(record[0], "currency") {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil {
+			return emptyExchangeRatesRes, fmt.Errorf("exchange rate line %d: %w", line, common.ErrNotMatch)
+		}
+
+		rate, err := newExchangeRate(record[0], value, "import")
+		if err != nil {
+			return emptyExchangeRatesRes, fmt.Errorf("exchange rate line %d: %w", line, err)
+		}
+
+		if first, ok := lines[rate.Currency]; ok {
+			return emptyExchangeRatesRes, common.Invalid(fmt.Errorf("exchange rate line %d: currency %s is already on line %d", line, rate.Currency, first))
+		}
+		lines[rate.Currency] = line
+
+		rates = append(rates, rate)
+	}
+
+	if len(rates) == 0 {
+		return emptyExchangeRatesRes, fmt.Errorf("exchange rate file : %w", common.ErrNotFound)
+	}
+
+	rates, err := s.Repo.SaveRates(rates)
+	if err != nil {
+		return emptyExchangeRatesRes, fmt.Errorf("SaveRates call failed: %w", err)
+	}
+
+	return model.ExchangeRatesFormatRes(rates), nil
+}
+
+// FindAllExchangeRate implements ExchangeRateService
+func (s *exchangeRateService) FindAllExchangeRate() ([]model.ExchangeRateRes, error) {
+	rates, err := s.Repo.FindAllRates()
+	if err != nil {
+		return emptyExchangeRatesRes, fmt.Errorf("FindAllRates call failed: %w", err)
+	}
+
+	return model.ExchangeRatesFormatRes(rates), nil
+}
+
+func newExchangeRate(currency string, value float64, source string) (model.ExchangeRate, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+
+	if currency == model.BaseCurrency || !model.IsSupportedCurrency(currency) {
+		return model.ExchangeRate{}, fmt.Errorf("currency %q: %w", currency, common.ErrUnsupportedCurrency)
+	}
+
+	if value <= 0 {
+		return model.ExchangeRate{}, fmt.Errorf("exchange rate %s: %w", currency, common.ErrNotMatch)
+	}
+
+	rate := model.ExchangeRate{
+		Currency: currency,
+		Rate:     value,
+		Source:   source,
+	}
+
+	return rate, nil
+}
@@ -0,0 +1,36 @@
+package service_test
+
+import (
+	"learn/common"
+	"learn/service"
+	"strings"
+	"testing"
+)
+
+func TestImportExchangeRates(t *testing.T) {
+	t.Run("saves every rate", func(t *testing.T) {
+		repo := &fakeRateRepository{}
+		srv := service.NewExchangeRateService(repo)
+
+		rates, err := srv.ImportExchangeRates(strings.NewReader("currency,rate\nusd,15500\nSGD,11500.5\n"))
+		if err != nil {
+			t.Fatalf("ImportExchangeRates: %v", err)
+		}
+		if len(rates) != 2 || rates[0].Currency != "USD" || len(repo.rates) != 2 {
+			t.Errorf("rates = %+v, want USD and SGD saved", rates)
+		}
+	})
+
+	t.Run("rejects a currency listed twice", func(t *testing.T) {
+		repo := &fakeRateRepository{}
+		srv := service.NewExchangeRateService(repo)
+
+		_, err := srv.ImportExchangeRates(strings.NewReader("USD,15500\nSGD,11500\nusd,15600\n"))
+		if common.AsError(err).Kind != common.KindValidation {
+			t.Fatalf("ImportExchangeRates error = %v, want a validation error", err)
+		}
+		if !strings.Contains(err.Error(), "line 3") || len(repo.rates) != 0 {
+			t.Errorf("error = %v, saved %d rates, want line 3 reported and nothing saved", err, len(repo.rates))
+		}
+	})
+}
@@ -0,0 +1,433 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"learn/common"
+	"learn/model"
+	"sync"
+	"time"
+)
+
+// fakeInventory keeps a running balance per product instead of a ledger,
+// and remembers the movements it was told about.
+type fakeInventory struct {
+	mu       sync.Mutex
+	balances map[int]int
+	notified []model.InventoryMovement
+}
+
+func newFakeInventory() *fakeInventory {
+	return &fakeInventory{balances: map[int]int{}}
+}
+
+func (f *fakeInventory) RecordMovement(req model.InventoryMovementReq, productId int, userId int) (model.InventoryMovementRes, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.balances[productId] += req.Quantity
+
+	return model.InventoryMovementRes{
+		ProductId:    productId,
+		Type:         req.Type,
+		Quantity:     req.Quantity,
+		Reason:       req.Reason,
+		BalanceAfter: f.balances[productId],
+		UserId:       userId,
+	}, nil
+}
+
+func (f *fakeInventory) FindMovementsByProductId(productId int) ([]model.InventoryMovementRes, error) {
+	return []model.InventoryMovementRes{}, nil
+}
+
+func (f *fakeInventory) Reconcile() ([]model.StockDriftRes, error) {
+	return []model.StockDriftRes{}, nil
+}
+
+func (f *fakeInventory) NotifyMovement(movement model.InventoryMovement) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.notified = append(f.notified, movement)
+}
+
+// fakeImageStore keeps image files in memory, and fails every save when
+// err is set.
+type fakeImageStore struct {
+	mu    sync.Mutex
+	files map[string][]byte
+	err   error
+}
+
+func newFakeImageStore() *fakeImageStore {
+	return &fakeImageStore{files: map[string][]byte{}}
+}
+
+func (f *fakeImageStore) Save(fileName string, data []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return f.err
+	}
+
+	f.files[fileName] = data
+	return nil
+}
+
+func (f *fakeImageStore) Remove(fileName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.files, fileName)
+	return nil
+}
+
+func (f *fakeImageStore) Writable() error {
+	return nil
+}
+
+func (f *fakeImageStore) has(fileName string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, ok := f.files[fileName]
+	return ok
+}
+
+// fakeTaxRepository serves a fixed set of tax classes.
+type fakeTaxRepository struct {
+	taxClasses []model.TaxClass
+}
+
+func (f *fakeTaxRepository) CreateTaxClass(taxClass model.TaxClass) (model.TaxClass, error) {
+	taxClass.Id = len(f.taxClasses) + 1
+	f.taxClasses = append(f.taxClasses, taxClass)
+	return taxClass, nil
+}
+
+func (f *fakeTaxRepository) FindAllTaxClass() ([]model.TaxClass, error) {
+	return f.taxClasses, nil
+}
+
+func (f *fakeTaxRepository) FindTaxClassById(taxClassId int) (model.TaxClass, error) {
+	for _, taxClass := range f.taxClasses {
+		if taxClass.Id == taxClassId {
+			return taxClass, nil
+		}
+	}
+
+	return model.TaxClass{}, fmt.Errorf("tax class %d: %w", taxClassId, common.ErrNotFound)
+}
+
+func (f *fakeTaxRepository) UpdateTaxClass(taxClass model.TaxClass) (model.TaxClass, error) {
+	return taxClass, nil
+}
+
+// fakeRateRepository serves a fixed set of exchange rates.
+type fakeRateRepository struct {
+	rates []model.ExchangeRate
+}
+
+func (f *fakeRateRepository) SaveRates(rates []model.ExchangeRate) ([]model.ExchangeRate, error) {
+	f.rates = rates
+	return rates, nil
+}
+
+func (f *fakeRateRepository) FindAllRates() ([]model.ExchangeRate, error) {
+	return f.rates, nil
+}
+
+func (f *fakeRateRepository) FindRateByCurrency(currency string) (model.ExchangeRate, error) {
+	for _, rate := range f.rates {
+		if rate.Currency == currency {
+			return rate, nil
+		}
+	}
+
+	return model.ExchangeRate{}, fmt.Errorf("exchange rate %s: %w", currency, common.ErrNotFound)
+}
+
+// fakeNotificationRepository keeps notifications, subscriptions and the
+// low-stock products in memory.
+type fakeNotificationRepository struct {
+	notifications []model.Notification
+	subscriptions []model.StockSubscription
+	lowStock      []model.Product
+	alerted       map[int]bool
+}
+
+func (f *fakeNotificationRepository) FindNotificationsByUserId(userId int) ([]model.Notification, error) {
+	notifications := []model.Notification{}
+	for _, notification := range f.notifications {
+		if notification.UserId == userId {
+			notifications = append(notifications, notification)
+		}
+	}
+
+	return notifications, nil
+}
+
+func (f *fakeNotificationRepository) MarkNotificationRead(notificationId int, userId int) error {
+	return nil
+}
+
+func (f *fakeNotificationRepository) CreateStockSubscription(subscription model.StockSubscription) (model.StockSubscription, error) {
+	subscription.Id = len(f.subscriptions) + 1
+	f.subscriptions = append(f.subscriptions, subscription)
+	return subscription, nil
+}
+
+func (f *fakeNotificationRepository) FindPendingStockSubscriptions(productId int) ([]model.StockSubscription, error) {
+	subscriptions := []model.StockSubscription{}
+	for _, subscription := range f.subscriptions {
+		if subscription.ProductId == productId && subscription.NotifiedAt == nil {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+
+	return subscriptions, nil
+}
+
+func (f *fakeNotificationRepository) MarkStockSubscriptionsNotified(subscriptionIds []int, notifications []model.Notification) ([]int, error) {
+	now := time.Now()
+	f.notifications = append(f.notifications, notifications...)
+
+	for i := range f.subscriptions {
+		for _, id := range subscriptionIds {
+			if f.subscriptions[i].Id == id {
+				f.subscriptions[i].NotifiedAt = &now
+			}
+		}
+	}
+
+	return subscriptionIds, nil
+}
+
+func (f *fakeNotificationRepository) FindLowStockProducts() ([]model.Product, error) {
+	products := []model.Product{}
+	for _, product := range f.lowStock {
+		if !f.alerted[product.Id] {
+			products = append(products, product)
+		}
+	}
+
+	return products, nil
+}
+
+func (f *fakeNotificationRepository) MarkLowStockAlerted(productIds []int, notifications []model.Notification) ([]int, error) {
+	if f.alerted == nil {
+		f.alerted = map[int]bool{}
+	}
+	for _, id := range productIds {
+		f.alerted[id] = true
+	}
+	f.notifications = append(f.notifications, notifications...)
+
+	return productIds, nil
+}
+
+func (f *fakeNotificationRepository) ResetLowStockAlerts() error {
+	return nil
+}
+
+// fakeMailer records every mail and fails them all when err is set.
+type fakeMailer struct {
+	sent [][]string
+	err  error
+}
+
+func (f *fakeMailer) Send(to []string, subject string, body string) error {
+	f.sent = append(f.sent, to)
+	return f.err
+}
+
+// fakeImportRepository keeps import jobs in memory.
+type fakeImportRepository struct {
+	mu   sync.Mutex
+	jobs map[int]model.ProductImportJob
+}
+
+func (f *fakeImportRepository) CreateJob(job model.ProductImportJob) (model.ProductImportJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.jobs == nil {
+		f.jobs = map[int]model.ProductImportJob{}
+	}
+	job.Id = len(f.jobs) + 1
+	f.jobs[job.Id] = job
+	return job, nil
+}
+
+func (f *fakeImportRepository) UpdateJob(job model.ProductImportJob) (model.ProductImportJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.jobs[job.Id] = job
+	return job, nil
+}
+
+func (f *fakeImportRepository) FindJobById(jobId int) (model.ProductImportJob, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	job, ok := f.jobs[jobId]
+	if !ok {
+		return model.ProductImportJob{}, fmt.Errorf("product import job %d: %w", jobId, common.ErrNotFound)
+	}
+
+	return job, nil
+}
+
+// fakeStockRepository is both the inventory and the warehouse repository,
+// keeping product totals and warehouse stock in memory the way
+// bookMovement and adjustWarehouseStock do.
+type fakeStockRepository struct {
+	mu         sync.Mutex
+	quantities map[int]int
+	warehouses []model.Warehouse
+	stocks     map[[2]int]int
+	movements  []model.InventoryMovement
+}
+
+func newFakeStockRepository(quantities map[int]int, warehouses ...model.Warehouse) *fakeStockRepository {
+	return &fakeStockRepository{quantities: quantities, warehouses: warehouses, stocks: map[[2]int]int{}}
+}
+
+func (f *fakeStockRepository) RecordMovement(movement model.InventoryMovement) (model.InventoryMovement, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.book(movement)
+}
+
+func (f *fakeStockRepository) book(movement model.InventoryMovement) (model.InventoryMovement, error) {
+	quantity, ok := f.quantities[movement.ProductId]
+	if !ok {
+		return model.InventoryMovement{}, fmt.Errorf("product %d: %w", movement.ProductId, common.ErrNotFound)
+	}
+	if quantity+movement.Quantity < 0 {
+		return model.InventoryMovement{}, fmt.Errorf("product %d: %w", movement.ProductId, common.ErrInsufficientStock)
+	}
+
+	if movement.WarehouseId != 0 {
+		err := f.adjust(movement.WarehouseId, movement.ProductId, movement.Quantity)
+		if err != nil {
+			return model.InventoryMovement{}, err
+		}
+	}
+
+	f.quantities[movement.ProductId] = quantity + movement.Quantity
+	movement.Id = len(f.movements) + 1
+	movement.BalanceAfter = quantity + movement.Quantity
+	f.movements = append(f.movements, movement)
+
+	return movement, nil
+}
+
+func (f *fakeStockRepository) adjust(warehouseId int, productId int, quantity int) error {
+	_, err := f.findWarehouse(warehouseId)
+	if err != nil {
+		return err
+	}
+
+	key := [2]int{warehouseId, productId}
+	if f.stocks[key]+quantity < 0 {
+		return fmt.Errorf("warehouse %d product %d: %w", warehouseId, productId, common.ErrInsufficientStock)
+	}
+	f.stocks[key] += quantity
+
+	return nil
+}
+
+func (f *fakeStockRepository) FindMovementsByProductId(productId int) ([]model.InventoryMovement, error) {
+	return f.movements, nil
+}
+
+func (f *fakeStockRepository) FindStockDrift() ([]model.StockDrift, error) {
+	return []model.StockDrift{}, nil
+}
+
+func (f *fakeStockRepository) CreateWarehouse(ctx context.Context, warehouse model.Warehouse) (model.Warehouse, error) {
+	warehouse.Id = len(f.warehouses) + 1
+	f.warehouses = append(f.warehouses, warehouse)
+	return warehouse, nil
+}
+
+func (f *fakeStockRepository) FindAllWarehouse(ctx context.Context) ([]model.Warehouse, error) {
+	return f.warehouses, nil
+}
+
+func (f *fakeStockRepository) FindWarehouseById(ctx context.Context, warehouseId int) (model.Warehouse, error) {
+	return f.findWarehouse(warehouseId)
+}
+
+func (f *fakeStockRepository) findWarehouse(warehouseId int) (model.Warehouse, error) {
+	for _, warehouse := range f.warehouses {
+		if warehouse.Id == warehouseId {
+			return warehouse, nil
+		}
+	}
+
+	return model.Warehouse{}, fmt.Errorf("warehouse %d: %w", warehouseId, common.ErrNotFound)
+}
+
+func (f *fakeStockRepository) UpdateWarehouse(ctx context.Context, warehouse model.Warehouse) (model.Warehouse, error) {
+	return warehouse, nil
+}
+
+func (f *fakeStockRepository) FindStocksByWarehouseId(ctx context.Context, warehouseId int) ([]model.WarehouseStock, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stocks := []model.WarehouseStock{}
+	for key, quantity := range f.stocks {
+		if key[0] == warehouseId {
+			stocks = append(stocks, model.WarehouseStock{WarehouseId: key[0], ProductId: key[1], Quantity: quantity})
+		}
+	}
+
+	return stocks, nil
+}
+
+func (f *fakeStockRepository) FindStocksByProductId(ctx context.Context, productId int) ([]model.WarehouseStock, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stocks := []model.WarehouseStock{}
+	for key, quantity := range f.stocks {
+		if key[1] == productId {
+			warehouse, _ := f.findWarehouse(key[0])
+			stocks = append(stocks, model.WarehouseStock{WarehouseId: key[0], ProductId: key[1], Quantity: quantity, Warehouse: warehouse})
+		}
+	}
+
+	return stocks, nil
+}
+
+func (f *fakeStockRepository) TransferStock(ctx context.Context, transfer model.StockTransfer) (model.StockTransfer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	err := f.adjust(transfer.FromWarehouseId, transfer.ProductId, -transfer.Quantity)
+	if err != nil {
+		return model.StockTransfer{}, err
+	}
+
+	err = f.adjust(transfer.ToWarehouseId, transfer.ProductId, transfer.Quantity)
+	if err != nil {
+		f.stocks[[2]int{transfer.FromWarehouseId, transfer.ProductId}] += transfer.Quantity
+		return model.StockTransfer{}, err
+	}
+
+	return transfer, nil
+}
+
+func (f *fakeStockRepository) AllocateStock(ctx context.Context, movement model.InventoryMovement) (model.InventoryMovement, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.book(movement)
+}
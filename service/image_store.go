@@ -0,0 +1,85 @@
+package service
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ImageStore keeps the files of uploaded images.
+type ImageStore interface {
+	Save(fileName string, data []byte) error
+	Remove(fileName string) error
+	// Writable reports why new files can't be saved, if they can't.
+	Writable() error
+}
+
+type diskImageStore struct {
+	Dir string
+}
+
+func NewDiskImageStore(dir string) ImageStore {
+	return &diskImageStore{
+		Dir: dir,
+	}
+}
+
+// Save implements ImageStore. Files are named by their content, so an
+// existing file already holds the same bytes and is left alone. New files
+// are written under a temporary name and renamed into place so a reader
+// never sees half a file.
+func (s *diskImageStore) Save(fileName string, data []byte) error {
+	path := filepath.Join(s.Dir, fileName)
+
+	_, err := os.Stat(path)
+	if err == nil {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(s.Dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Remove implements ImageStore. Removing a missing file is not an error.
+func (s *diskImageStore) Remove(fileName string) error {
+	err := os.Remove(filepath.Join(s.Dir, fileName))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// Writable implements ImageStore by creating and removing a temporary file,
+// the same way Save does.
+func (s *diskImageStore) Writable() error {
+	tmp, err := os.CreateTemp(s.Dir, ".health-*")
+	if err != nil {
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Remove(tmp.Name())
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"fmt"
+	"learn/common"
+	"learn/model"
+	"learn/repository"
+	"log/slog"
+)
+
+type InventoryService interface {
+	// ADMIN
+	RecordMovement(req model.InventoryMovementReq, productId int, userId int) (model.InventoryMovementRes, error)
+	FindMovementsByProductId(productId int) ([]model.InventoryMovementRes, error)
+	Reconcile() ([]model.StockDriftRes, error)
+
+	// SYSTEM
+	NotifyMovement(movement model.InventoryMovement)
+}
+
+type inventoryService struct {
+	Repo     repository.InventoryRepository
+	Notifier NotificationService
+}
+
+func NewInventoryService(repo repository.InventoryRepository, notifier NotificationService) InventoryService {
+	return &inventoryService{
+		Repo:     repo,
+		Notifier: notifier,
+	}
+}
+
+var (
+	emptyInventoryMovementRes  = model.InventoryMovementRes{}
+	emptyInventoryMovementsRes = []model.InventoryMovementRes{}
+	emptyStockDriftsRes        = []model.StockDriftRes{}
+)
+
+// RecordMovement implements InventoryService. Restock, cancellation and
+// return quantities add stock and sales remove it, so those are given as a
+// positive count; adjustments carry their own sign and need a reason.
+func (s *inventoryService) RecordMovement(req model.InventoryMovementReq, productId int, userId int) (model.InventoryMovementRes, error) {
+	quantity := req.Quantity
+
+	switch req.Type {
+	case model.MovementRestock, model.MovementCancellation, model.MovementReturn:
+		if quantity < 0 {
+			return emptyInventoryMovementRes, fmt.Errorf("%s quantity %d : %w", req.Type, quantity, common.ErrInvalidMovement)
+		}
+	case model.MovementSale:
+		if quantity < 0 {
+			return emptyInventoryMovementRes, fmt.Errorf("%s quantity %d : %w", req.Type, quantity, common.ErrInvalidMovement)
+		}
+		quantity = -quantity
+	case model.MovementAdjustment:
+		if req.Reason == "" {
+			return emptyInventoryMovementRes, fmt.Errorf("%s reason : %w", req.Type, common.ErrInvalidMovement)
+		}
+	default:
+		return emptyInventoryMovementRes, fmt.Errorf("type %q : %w", req.Type, common.ErrInvalidMovement)
+	}
+
+	movement := model.InventoryMovement{
+		ProductId:   productId,
+		WarehouseId: req.WarehouseId,
+		Type:        req.Type,
+		Quantity:    quantity,
+		Reason:      req.Reason,
+		UserId:      userId,
+	}
+
+	movement, err := s.Repo.RecordMovement(movement)
+	if err != nil {
+		return emptyInventoryMovementRes, fmt.Errorf("RecordMovement call failed: %w", err)
+	}
+
+	s.NotifyMovement(movement)
+
+	return model.InventoryMovementFormatRes(movement), nil
+}
+
+// NotifyMovement implements InventoryService. It tells subscribers when a
+// committed movement brought a product back in stock; a failed
+// notification is logged and does not undo the movement.
+func (s *inventoryService) NotifyMovement(movement model.InventoryMovement) {
+	if movement.BalanceAfter <= 0 || movement.BalanceAfter-movement.Quantity != 0 {
+		return
+	}
+
+	err := s.Notifier.NotifyBackInStock(movement.ProductId)
+	if err != nil {
+		slog.Error("back in stock notification failed", "product_id", movement.ProductId, "error", err)
+	}
+}
+
+// FindMovementsByProductId implements InventoryService
+func (s *inventoryService) FindMovementsByProductId(productId int) ([]model.InventoryMovementRes, error) {
+	movements, err := s.Repo.FindMovementsByProductId(productId)
+	if err != nil {
+		return emptyInventoryMovementsRes, fmt.Errorf("FindMovementsByProductId call failed: %w", err)
+	}
+
+	return model.InventoryMovementsFormatRes(movements), nil
+}
+
+// Reconcile implements InventoryService. It lists every product whose
+// stored quantity differs from the sum of its ledger.
+func (s *inventoryService) Reconcile() ([]model.StockDriftRes, error) {
+	drifts, err := s.Repo.FindStockDrift()
+	if err != nil {
+		return emptyStockDriftsRes, fmt.Errorf("FindStockDrift call failed: %w", err)
+	}
+
+	return model.StockDriftsFormatRes(drifts), nil
+}
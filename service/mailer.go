@@ -0,0 +1,64 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"net/smtp"
+	"strings"
+)
+
+type Mailer interface {
+	Send(to []string, subject string, body string) error
+}
+
+type smtpMailer struct {
+	Addr string
+	Auth smtp.Auth
+	From string
+}
+
+// NewSMTPMailer sends plain-text mail through an SMTP server. Without a
+// username the server is used unauthenticated.
+func NewSMTPMailer(host string, port string, username string, password string, from string) Mailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &smtpMailer{
+		Addr: host + ":" + port,
+		Auth: auth,
+		From: from,
+	}
+}
+
+// Send implements Mailer
+func (m *smtpMailer) Send(to []string, subject string, body string) error {
+	if len(to) == 0 {
+		return nil
+	}
+
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		m.From, strings.Join(to, ", "), subject, body)
+
+	err := smtp.SendMail(m.Addr, m.Auth, m.From, to, []byte(message))
+	if err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+
+	return nil
+}
+
+type logMailer struct{}
+
+// NewLogMailer writes mail to the log instead of sending it, for
+// environments without an SMTP server.
+func NewLogMailer() Mailer {
+	return &logMailer{}
+}
+
+// Send implements Mailer
+func (m *logMailer) Send(to []string, subject string, body string) error {
+	slog.Info("mail", "to", strings.Join(to, ", "), "subject", subject, "body", body)
+	return nil
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"learn/model"
+	"learn/repository"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+type NotificationService interface {
+	// USER
+	SubscribeBackInStock(ctx context.Context, productId int, userId int) (model.MessageResponse, error)
+	FindNotifications(userId int) ([]model.NotificationRes, error)
+	MarkNotificationRead(notificationId int, userId int) (model.MessageResponse, error)
+
+	// SYSTEM
+	CheckLowStock(ctx context.Context) error
+	NotifyBackInStock(productId int) error
+}
+
+type notificationService struct {
+	Repo        repository.NotificationRepository
+	UserRepo    repository.UserRepository
+	ProductRepo repository.ProductRepository
+	Mailer      Mailer
+}
+
+func NewNotificationService(repo repository.NotificationRepository, userRepo repository.UserRepository, productRepo repository.ProductRepository, mailer Mailer) NotificationService {
+	return &notificationService{
+		Repo:        repo,
+		UserRepo:    userRepo,
+		ProductRepo: productRepo,
+		Mailer:      mailer,
+	}
+}
+
+var (
+	emptyNotificationsRes = []model.NotificationRes{}
+)
+
+// SubscribeBackInStock implements NotificationService
+func (s *notificationService) SubscribeBackInStock(ctx context.Context, productId int, userId int) (model.MessageResponse, error) {
+	product, err := s.ProductRepo.FindProductById(ctx, productId)
+	if err != nil {
+		return emptyMessageRes, fmt.Errorf("FindProductById call failed: %w", err)
+	}
+
+	subscription := model.StockSubscription{
+		ProductId: productId,
+		UserId:    userId,
+	}
+
+	_, err = s.Repo.CreateStockSubscription(subscription)
+	if err != nil {
+		return emptyMessageRes, fmt.Errorf("CreateStockSubscription call failed: %w", err)
+	}
+
+	response := model.MessageResponse{
+		Message: fmt.Sprintf("you will be notified when %s is back in stock", product.Name),
+	}
+
+	return response, nil
+}
+
+// FindNotifications implements NotificationService
+func (s *notificationService) FindNotifications(userId int) ([]model.NotificationRes, error) {
+	notifications, err := s.Repo.FindNotificationsByUserId(userId)
+	if err != nil {
+		return emptyNotificationsRes, fmt.Errorf("FindNotificationsByUserId call failed: %w", err)
+	}
+
+	return model.NotificationsFormatRes(notifications), nil
+}
+
+// MarkNotificationRead implements NotificationService
+func (s *notificationService) MarkNotificationRead(notificationId int, userId int) (model.MessageResponse, error) {
+	err := s.Repo.MarkNotificationRead(notificationId, userId)
+	if err != nil {
+		return emptyMessageRes, fmt.Errorf("MarkNotificationRead call failed: %w", err)
+	}
+
+	response := model.MessageResponse{
+		Message: fmt.Sprintf("notification id %d marked as read", notificationId),
+	}
+
+	return response, nil
+}
+
+// CheckLowStock implements NotificationService. Every admin gets one alert
+// per product that fell to or below its threshold; the product is not
+// alerted again until its stock has recovered. The in-app alerts are saved
+// together with the mark on the product, and the mail is sent afterwards
+// on a best-effort basis, so an unreachable mail server neither loses nor
+// repeats alerts.
+func (s *notificationService) CheckLowStock(ctx context.Context) error {
+	err := s.Repo.ResetLowStockAlerts()
+	if err != nil {
+		return fmt.Errorf("ResetLowStockAlerts call failed: %w", err)
+	}
+
+	products, err := s.Repo.FindLowStockProducts()
+	if err != nil {
+		return fmt.Errorf("FindLowStockProducts call failed: %w", err)
+	}
+
+	if len(products) == 0 {
+		return nil
+	}
+
+	admins, err := s.UserRepo.FindByRole(ctx, "admin")
+	if err != nil {
+		return fmt.Errorf("FindByRole call failed: %w", err)
+	}
+
+	notifications := []model.Notification{}
+	productIds := []int{}
+	messages := map[int]string{}
+
+	for _, product := range products {
+		message := fmt.Sprintf("%s is low on stock: %d left (threshold %d)", product.Name, product.Quantity, product.LowStockThreshold)
+
+		for _, admin := range admins {
+			notification := model.Notification{
+				UserId:    admin.Id,
+				Type:      model.NotificationLowStock,
+				ProductId: product.Id,
+				Message:   message,
+			}
+			notifications = append(notifications, notification)
+		}
+
+		productIds = append(productIds, product.Id)
+		messages[product.Id] = message
+	}
+
+	alerted, err := s.Repo.MarkLowStockAlerted(productIds, notifications)
+	if err != nil {
+		return fmt.Errorf("MarkLowStockAlerted call failed: %w", err)
+	}
+
+	if len(alerted) == 0 {
+		return nil
+	}
+
+	lines := []string{}
+	for _, productId := range alerted {
+		lines = append(lines, messages[productId])
+	}
+	body := strings.Join(lines, "\n")
+
+	// Each admin gets a mail of their own, so their addresses are not
+	// shown to each other.
+	for _, admin := range admins {
+		err = s.Mailer.Send([]string{admin.Email}, "Low stock alert", body)
+		if err != nil {
+			slog.ErrorContext(ctx, "low stock mail failed", "user_id", admin.Id, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// NotifyBackInStock implements NotificationService. Customers waiting for
+// the product get an in-app notification and an email, once. The
+// notifications are saved together with the mark on the subscriptions,
+// and the mail is sent afterwards on a best-effort basis.
+func (s *notificationService) NotifyBackInStock(productId int) error {
+	// Stock has already been recorded when this runs, so the notifications
+	// are sent even if the request that triggered them is cancelled.
+	ctx := context.Background()
+
+	subscriptions, err := s.Repo.FindPendingStockSubscriptions(productId)
+	if err != nil {
+		return fmt.Errorf("FindPendingStockSubscriptions call failed: %w", err)
+	}
+
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	product, err := s.ProductRepo.FindProductById(ctx, productId)
+	if err != nil {
+		return fmt.Errorf("FindProductById call failed: %w", err)
+	}
+
+	message := fmt.Sprintf("%s is back in stock", product.Name)
+	notifications := []model.Notification{}
+	subscriptionIds := []int{}
+	userIds := map[int]int{}
+
+	for _, subscription := range subscriptions {
+		notification := model.Notification{
+			UserId:    subscription.UserId,
+			Type:      model.NotificationBackInStock,
+			ProductId: productId,
+			Message:   message,
+		}
+		notifications = append(notifications, notification)
+		subscriptionIds = append(subscriptionIds, subscription.Id)
+		userIds[subscription.Id] = subscription.UserId
+	}
+
+	notified, err := s.Repo.MarkStockSubscriptionsNotified(subscriptionIds, notifications)
+	if err != nil {
+		return fmt.Errorf("MarkStockSubscriptionsNotified call failed: %w", err)
+	}
+
+	for _, subscriptionId := range notified {
+		user, err := s.UserRepo.FindByID(ctx, userIds[subscriptionId])
+		if err != nil {
+			slog.Error("back in stock mail failed", "user_id", userIds[subscriptionId], "error", err)
+			continue
+		}
+
+		err = s.Mailer.Send([]string{user.Email}, message, fmt.Sprintf("Good news, %s. %s.", user.Username, message))
+		if err != nil {
+			slog.Error("back in stock mail failed", "user_id", user.Id, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// RunLowStockChecker calls CheckLowStock every interval until ctx is done.
+func RunLowStockChecker(ctx context.Context, srv NotificationService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := srv.CheckLowStock(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "low stock check failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
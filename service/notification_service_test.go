@@ -0,0 +1,72 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"learn/model"
+	"learn/repository/repotest"
+	"learn/service"
+	"testing"
+)
+
+func TestCheckLowStockWithoutMail(t *testing.T) {
+	ctx := context.Background()
+	users := repotest.NewUserRepository()
+	first, _ := users.CreateUser(ctx, repotest.NewAdmin())
+	second, _ := users.CreateUser(ctx, repotest.NewAdmin())
+	product := repotest.NewProduct(func(p *model.Product) { p.Id = 1; p.Quantity = 1 })
+
+	repo := &fakeNotificationRepository{lowStock: []model.Product{product}}
+	mailer := &fakeMailer{err: errors.New("connection refused")}
+	srv := service.NewNotificationService(repo, users, repotest.NewProductRepository(product), mailer)
+
+	err := srv.CheckLowStock(ctx)
+	if err != nil {
+		t.Fatalf("CheckLowStock: %v", err)
+	}
+
+	if len(repo.notifications) != 2 || !repo.alerted[product.Id] {
+		t.Fatalf("notifications = %+v, alerted = %v, want both admins alerted despite the mail failure", repo.notifications, repo.alerted)
+	}
+
+	if len(mailer.sent) != 2 {
+		t.Fatalf("sent %d mails, want one per admin", len(mailer.sent))
+	}
+	for i, admin := range []model.User{first, second} {
+		if len(mailer.sent[i]) != 1 || mailer.sent[i][0] != admin.Email {
+			t.Errorf("mail %d went to %v, want only %s", i, mailer.sent[i], admin.Email)
+		}
+	}
+
+	err = srv.CheckLowStock(ctx)
+	if err != nil {
+		t.Fatalf("CheckLowStock again: %v", err)
+	}
+	if len(repo.notifications) != 2 || len(mailer.sent) != 2 {
+		t.Errorf("second check sent %d notifications and %d mails, want no repeat", len(repo.notifications), len(mailer.sent))
+	}
+}
+
+func TestNotifyBackInStockWithoutMail(t *testing.T) {
+	ctx := context.Background()
+	users := repotest.NewUserRepository()
+	user, _ := users.CreateUser(ctx, repotest.NewUser())
+	products := repotest.NewProductRepository()
+	product, _, _ := products.CreateProduct(ctx, repotest.NewProduct(), model.InventoryMovement{})
+
+	repo := &fakeNotificationRepository{}
+	repo.CreateStockSubscription(model.StockSubscription{ProductId: product.Id, UserId: user.Id})
+	mailer := &fakeMailer{err: errors.New("connection refused")}
+	srv := service.NewNotificationService(repo, users, products, mailer)
+
+	for i := 0; i < 2; i++ {
+		err := srv.NotifyBackInStock(product.Id)
+		if err != nil {
+			t.Fatalf("NotifyBackInStock: %v", err)
+		}
+	}
+
+	if len(repo.notifications) != 1 || len(mailer.sent) != 1 {
+		t.Errorf("sent %d notifications and %d mails, want one of each", len(repo.notifications), len(mailer.sent))
+	}
+}
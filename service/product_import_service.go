@@ -0,0 +1,520 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"learn/common"
+	"learn/config"
+	"learn/model"
+	"learn/repository"
+	"log/slog"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Product file formats
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// importProgressEvery is how many rows an import processes between job
+// status updates.
+const importProgressEvery = 100
+
+type ProductImportService interface {
+	// ADMIN
+	ExportProducts(ctx context.Context, format string, w io.Writer) error
+	StartImport(file io.Reader, fileName string, format string, dryRun bool, userId int) (model.ProductImportJobRes, error)
+	FindImportJob(jobId int) (model.ProductImportJobRes, error)
+	// SYSTEM
+	Wait()
+}
+
+type productImportService struct {
+	Repo        repository.ProductImportRepository
+	ProductRepo repository.ProductRepository
+	Products    ProductService
+	Validate    *config.Validator
+	// running counts the imports started and not yet finished.
+	running sync.WaitGroup
+}
+
+func NewProductImportService(repo repository.ProductImportRepository, productRepo repository.ProductRepository, products ProductService, validate *config.Validator) ProductImportService {
+	return &productImportService{
+		Repo:        repo,
+		ProductRepo: productRepo,
+		Products:    products,
+		Validate:    validate,
+	}
+}
+
+var (
+	emptyProductImportJobRes = model.ProductImportJobRes{}
+)
+
+// ExportProducts implements ProductImportService. Trashed products are not
+// exported.
+func (s *productImportService) ExportProducts(ctx context.Context, format string, w io.Writer) error {
+	products, err := s.ProductRepo.FindAllProduct(ctx)
+	if err != nil {
+		return fmt.Errorf("FindAllProduct call failed: %w", err)
+	}
+
+	rows := [][]string{model.ProductFileColumns}
+	for _, product := range products {
+		rows = append(rows, []string{
+			product.Sku,
+			product.Barcode,
+			product.Name,
+			product.Description,
+			strconv.Itoa(product.Quantity),
+			product.Price.Decimal(),
+			product.Price.Currency,
+			strconv.Itoa(product.LowStockThreshold),
+			taxClassCell(product.TaxClassId),
+			strconv.FormatBool(product.TaxInclusive),
+			product.Slug,
+			product.MetaTitle,
+			product.MetaDescription,
+		})
+	}
+
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		err = writer.WriteAll(rows)
+	case FormatXLSX:
+		err = writeXLSX(w, rows)
+	default:
+		return fmt.Errorf("product file format %q : %w", format, common.ErrNotMatch)
+	}
+	if err != nil {
+		return fmt.Errorf("product export: %w", err)
+	}
+
+	return nil
+}
+
+// StartImport implements ProductImportService. The file is read and its
+// header checked before the job is created; the rows are then processed in
+// the background. Each row creates or updates the product with its SKU,
+// and a row that fails is reported on the job without stopping the others.
+// A dry run only validates the rows.
+func (s *productImportService) StartImport(file io.Reader, fileName string, format string, dryRun bool, userId int) (model.ProductImportJobRes, error) {
+	rows, err := readProductFile(file, format)
+	if err != nil {
+		return emptyProductImportJobRes, err
+	}
+
+	columns, err := importColumns(rows)
+	if err != nil {
+		return emptyProductImportJobRes, err
+	}
+
+	job := model.ProductImportJob{
+		UserId:    userId,
+		FileName:  fileName,
+		DryRun:    dryRun,
+		Status:    model.ImportJobPending,
+		TotalRows: len(rows) - 1,
+	}
+
+	job, err = s.Repo.CreateJob(job)
+	if err != nil {
+		return emptyProductImportJobRes, fmt.Errorf("CreateJob call failed: %w", err)
+	}
+
+	// The import outlives the request, so it does not run on the request's
+	// context.
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		s.runImport(context.Background(), job, columns, rows[1:])
+	}()
+
+	return model.ProductImportJobFormatRes(job), nil
+}
+
+// Wait implements ProductImportService. It blocks until every import
+// started so far has finished.
+func (s *productImportService) Wait() {
+	s.running.Wait()
+}
+
+// FindImportJob implements ProductImportService
+func (s *productImportService) FindImportJob(jobId int) (model.ProductImportJobRes, error) {
+	job, err := s.Repo.FindJobById(jobId)
+	if err != nil {
+		return emptyProductImportJobRes, fmt.Errorf("FindJobById call failed: %w", err)
+	}
+
+	return model.ProductImportJobFormatRes(job), nil
+}
+
+// runImport processes the rows of a job. A row that can't be imported is
+// reported on the job; an error that ends the import, or a panic, marks
+// the job as failed, so it is never left running.
+func (s *productImportService) runImport(ctx context.Context, job model.ProductImportJob, columns map[string]int, rows [][]string) {
+	job.Status = model.ImportJobRunning
+	s.saveJob(&job, nil)
+
+	rowErrors := []model.ImportRowError{}
+	seen := map[string]int{}
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		slog.Error("product import panicked", "job_id", job.Id, "panic", r, "stack", string(debug.Stack()))
+		s.failJob(&job, rowErrors, "internal error")
+	}()
+
+	for i, record := range rows {
+		row := i + 2
+		sku := cell(record, columns, "sku")
+
+		if first, ok := seen[sku]; ok && sku != "" {
+			rowErrors = append(rowErrors, model.ImportRowError{Row: row, Sku: sku, Field: "sku", Rule: "unique", Message: fmt.Sprintf("duplicate of row %d", first)})
+			job.FailedRows++
+		} else {
+			seen[sku] = row
+
+			created, errs, err := s.importRow(ctx, job, row, sku, record, columns)
+			if err != nil {
+				slog.Error("product import failed", "job_id", job.Id, "row", row, "error", err)
+				s.failJob(&job, rowErrors, fmt.Sprintf("row %d: %v", row, err))
+				return
+			}
+
+			switch {
+			case len(errs) > 0:
+				rowErrors = append(rowErrors, errs...)
+				job.FailedRows++
+			case created:
+				job.CreatedRows++
+			default:
+				job.UpdatedRows++
+			}
+		}
+
+		if (i+1)%importProgressEvery == 0 {
+			s.saveJob(&job, rowErrors)
+		}
+	}
+
+	now := time.Now()
+	job.Status = model.ImportJobCompleted
+	job.FinishedAt = &now
+	s.saveJob(&job, rowErrors)
+}
+
+// failJob ends job as failed. The reason is reported as an error without a
+// row, after the row errors found so far.
+func (s *productImportService) failJob(job *model.ProductImportJob, rowErrors []model.ImportRowError, reason string) {
+	rowErrors = append(rowErrors, model.ImportRowError{Rule: "internal", Message: "import stopped: " + reason})
+
+	now := time.Now()
+	job.Status = model.ImportJobFailed
+	job.FinishedAt = &now
+	s.saveJob(job, rowErrors)
+}
+
+// importRow applies one row and reports whether it created a product.
+// Stock changes are booked in the name of the user who started the job.
+// The error is set when the row can't be checked at all, such as when the
+// product lookup fails, which the next rows would run into as well.
+func (s *productImportService) importRow(ctx context.Context, job model.ProductImportJob, row int, sku string, record []string, columns map[string]int) (bool, []model.ImportRowError, error) {
+	rowError := func(field string, rule string, message string) []model.ImportRowError {
+		return []model.ImportRowError{{Row: row, Sku: sku, Field: field, Rule: rule, Message: message}}
+	}
+
+	if sku == "" {
+		return false, rowError("sku", "required", "sku is required"), nil
+	}
+
+	// A SKU no product has yet creates a new product.
+	existing, err := s.ProductRepo.FindProductBySku(ctx, sku)
+	if err != nil && !errors.Is(err, common.ErrNotFound) {
+		return false, nil, fmt.Errorf("FindProductBySku call failed: %w", err)
+	}
+
+	if existing.DeletedAt.Valid {
+		return false, rowError("sku", "trashed", "product with this sku is in the trash"), nil
+	}
+
+	// Columns left out of the file keep the product's current values.
+	req := model.ProductReq{Sku: sku, Price: model.Money{Currency: model.BaseCurrency}}
+	if existing.Id != 0 {
+		req = model.ProductReq{
+			Sku:               existing.Sku,
+			Barcode:           existing.Barcode,
+			Name:              existing.Name,
+			Description:       existing.Description,
+			Slug:              existing.Slug,
+			MetaTitle:         existing.MetaTitle,
+			MetaDescription:   existing.MetaDescription,
+			Quantity:          existing.Quantity,
+			LowStockThreshold: existing.LowStockThreshold,
+			Price:             existing.Price,
+			TaxInclusive:      existing.TaxInclusive,
+		}
+	}
+
+	if existing.TaxClassId != nil {
+		req.TaxClassId = *existing.TaxClassId
+	}
+
+	errs := []model.ImportRowError{}
+	setString := func(column string, dst *string) {
+		if _, ok := columns[column]; ok {
+			*dst = cell(record, columns, column)
+		}
+	}
+	setInt := func(column string, dst *int) {
+		if _, ok := columns[column]; !ok {
+			return
+		}
+		value := cell(record, columns, column)
+		if value == "" {
+			*dst = 0
+			return
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			errs = append(errs, rowError(column, "number", fmt.Sprintf("%q is not a whole number", value))...)
+			return
+		}
+		*dst = n
+	}
+
+	setString("barcode", &req.Barcode)
+	setString("name", &req.Name)
+	setString("description", &req.Description)
+	setString("slug", &req.Slug)
+	setString("meta_title", &req.MetaTitle)
+	setString("meta_description", &req.MetaDescription)
+	setInt("quantity", &req.Quantity)
+	setInt("low_stock_threshold", &req.LowStockThreshold)
+	setInt("tax_class_id", &req.TaxClassId)
+
+	if _, ok := columns["tax_inclusive"]; ok {
+		value := cell(record, columns, "tax_inclusive")
+		inclusive, err := strconv.ParseBool(value)
+		if value != "" && err != nil {
+			errs = append(errs, rowError("tax_inclusive", "boolean", fmt.Sprintf("%q is not true or false", value))...)
+		}
+		req.TaxInclusive = inclusive
+	}
+
+	currency := req.Price.Currency
+	setString("currency", &currency)
+	currency = strings.ToUpper(currency)
+	if currency == "" {
+		currency = model.BaseCurrency
+	}
+
+	amount := req.Price.Decimal()
+	setString("price", &amount)
+	if !model.IsSupportedCurrency(currency) {
+		errs = append(errs, rowError("currency", "currency", fmt.Sprintf("%q is not a supported currency", currency))...)
+	} else {
+		price, err := model.ParseMoney(amount, currency)
+		if err != nil {
+			errs = append(errs, rowError("price", "money", fmt.Sprintf("%q is not a valid %s amount", amount, currency))...)
+		}
+		req.Price = price
+	}
+
+	err = s.Validate.Struct(&req)
+	if err != nil {
+		fields := s.Validate.Fields(err, "en")
+		if fields == nil {
+			return false, nil, fmt.Errorf("Validate call failed: %w", err)
+		}
+
+		for _, field := range fields {
+			column, ok := importFieldColumns[field.Field]
+			if !ok {
+				column = field.Field
+			}
+			errs = append(errs, rowError(column, field.Rule, field.Message)...)
+		}
+	}
+
+	if len(errs) > 0 {
+		return false, errs, nil
+	}
+
+	if job.DryRun {
+		return existing.Id == 0, nil, nil
+	}
+
+	if existing.Id == 0 {
+		_, err = s.Products.AddProduct(ctx, req, job.UserId)
+	} else {
+		_, err = s.Products.UpdateProduct(ctx, req, existing.Id, job.UserId)
+	}
+	// The report goes back to the client, so like WriteError it only
+	// carries domain messages; internal errors are logged instead.
+	if err != nil {
+		domainErr := common.AsError(err)
+		if domainErr.Kind == common.KindInternal {
+			slog.Error("product import row failed", "job_id", job.Id, "row", row, "error", err)
+		}
+
+		return false, rowError("", "rejected", domainErr.Message), nil
+	}
+
+	return existing.Id == 0, nil, nil
+}
+
+func (s *productImportService) saveJob(job *model.ProductImportJob, rowErrors []model.ImportRowError) {
+	if rowErrors != nil {
+		data, err := json.Marshal(rowErrors)
+		if err != nil {
+			slog.Error("encode product import errors failed", "job_id", job.Id, "error", err)
+		}
+		job.Errors = string(data)
+	}
+
+	saved, err := s.Repo.UpdateJob(*job)
+	if err != nil {
+		slog.Error("UpdateJob call failed", "job_id", job.Id, "error", err)
+		return
+	}
+
+	*job = saved
+}
+
+// importFieldColumns maps ProductReq fields reported by the validator to
+// file columns where the two differ. The others share their JSON name.
+var importFieldColumns = map[string]string{
+	"price.amount":   "price",
+	"price.currency": "currency",
+}
+
+// readProductFile returns the rows of a CSV file or of the first sheet of
+// an XLSX workbook, header included.
+func readProductFile(file io.Reader, format string) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(file)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("product file: %w", err)
+		}
+
+		return rows, nil
+	case FormatXLSX:
+		workbook, err := excelize.OpenReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("product file: %w", err)
+		}
+		defer workbook.Close()
+
+		rows, err := workbook.GetRows(workbook.GetSheetName(0))
+		if err != nil {
+			return nil, fmt.Errorf("product file: %w", err)
+		}
+
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("product file format %q : %w", format, common.ErrNotMatch)
+	}
+}
+
+// importColumns maps the header names of a product file to their index.
+// The sku column is required; unknown columns reject the file.
+func importColumns(rows [][]string) (map[string]int, error) {
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("product file rows : %w", common.ErrNotFound)
+	}
+
+	known := map[string]bool{}
+	for _, column := range model.ProductFileColumns {
+		known[column] = true
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		if !known[name] {
+			return nil, fmt.Errorf("product file column %q : %w", name, common.ErrNotMatch)
+		}
+
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("product file column %q : %w", name, common.ErrExists)
+		}
+
+		columns[name] = i
+	}
+
+	if _, ok := columns["sku"]; !ok {
+		return nil, fmt.Errorf("product file column \"sku\" : %w", common.ErrNotFound)
+	}
+
+	return columns, nil
+}
+
+func cell(record []string, columns map[string]int, column string) string {
+	i, ok := columns[column]
+	if !ok || i >= len(record) {
+		return ""
+	}
+
+	return strings.TrimSpace(record[i])
+}
+
+// taxClassCell is the tax_class_id column of a product; it is empty when
+// the product has no tax class.
+func taxClassCell(taxClassId *int) string {
+	if taxClassId == nil {
+		return ""
+	}
+
+	return strconv.Itoa(*taxClassId)
+}
+
+func writeXLSX(w io.Writer, rows [][]string) error {
+	workbook := excelize.NewFile()
+	defer workbook.Close()
+
+	sheet := workbook.GetSheetName(0)
+	for i, row := range rows {
+		values := make([]interface{}, len(row))
+		for j, value := range row {
+			values[j] = value
+		}
+
+		address, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+
+		err = workbook.SetSheetRow(sheet, address, &values)
+		if err != nil {
+			return err
+		}
+	}
+
+	return workbook.Write(w)
+}
@@ -0,0 +1,114 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"learn/config"
+	"learn/model"
+	"learn/repository/repotest"
+	"learn/service"
+	"strings"
+	"testing"
+)
+
+// brokenProductRepository fails the lookup of SKU "BROKEN", panics on SKU
+// "PANIC", fails to create SKU "FAILS" and fails every product listing.
+type brokenProductRepository struct {
+	*repotest.ProductRepository
+}
+
+func (r brokenProductRepository) FindProductBySku(ctx context.Context, sku string) (model.Product, error) {
+	switch sku {
+	case "BROKEN":
+		return model.Product{}, errors.New("connection refused")
+	case "PANIC":
+		panic("unexpected row")
+	}
+
+	return r.ProductRepository.FindProductBySku(ctx, sku)
+}
+
+func (r brokenProductRepository) CreateProduct(ctx context.Context, product model.Product, stock model.InventoryMovement) (model.Product, model.InventoryMovement, error) {
+	if product.Sku == "FAILS" {
+		return model.Product{}, model.InventoryMovement{}, errors.New(`pq: relation "products" does not exist`)
+	}
+
+	return r.ProductRepository.CreateProduct(ctx, product, stock)
+}
+
+func (r brokenProductRepository) FindAllProduct(ctx context.Context) ([]model.Product, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestImportStopsAsFailed(t *testing.T) {
+	validate, err := config.NewValidator()
+	if err != nil {
+		t.Fatalf("NewValidator: %v", err)
+	}
+
+	for _, sku := range []string{"BROKEN", "PANIC"} {
+		t.Run(sku, func(t *testing.T) {
+			f := newProductFixture()
+			jobs := &fakeImportRepository{}
+			srv := service.NewProductImportService(jobs, brokenProductRepository{f.repo}, f.srv, validate)
+
+			file := "sku,name,description,quantity,price\n" +
+				"KOPI-1,Kopi Gayo,Fresh from the roastery,5,120000\n" +
+				sku + ",Kopi Toraja,Fresh from the roastery,5,120000\n" +
+				"KOPI-3,Kopi Bali,Fresh from the roastery,5,120000\n"
+
+			started, err := srv.StartImport(strings.NewReader(file), "products.csv", service.FormatCSV, false, 1)
+			if err != nil {
+				t.Fatalf("StartImport: %v", err)
+			}
+			srv.Wait()
+
+			job, err := srv.FindImportJob(started.Id)
+			if err != nil {
+				t.Fatalf("FindImportJob: %v", err)
+			}
+			if job.Status != model.ImportJobFailed || job.FinishedAt == nil {
+				t.Errorf("job status = %s, finished at %v, want failed and finished", job.Status, job.FinishedAt)
+			}
+			if job.CreatedRows != 1 {
+				t.Errorf("created rows = %d, want only the row before the failure", job.CreatedRows)
+			}
+			if len(job.Errors) != 1 || !strings.HasPrefix(job.Errors[0].Message, "import stopped") {
+				t.Errorf("errors = %+v, want the reason the import stopped", job.Errors)
+			}
+		})
+	}
+}
+
+func TestImportHidesInternalErrors(t *testing.T) {
+	validate, err := config.NewValidator()
+	if err != nil {
+		t.Fatalf("NewValidator: %v", err)
+	}
+
+	f := newProductFixture()
+	srv := service.NewProductService(brokenProductRepository{f.repo}, &fakeTaxRepository{}, &fakeRateRepository{}, f.inventory, f.images)
+	jobs := &fakeImportRepository{}
+	imports := service.NewProductImportService(jobs, f.repo, srv, validate)
+
+	file := "sku,name,description,quantity,price\n" +
+		"FAILS,Kopi Gayo,Fresh from the roastery,5,120000\n" +
+		"KOPI-2,Kopi Toraja,Fresh from the roastery,5,120000\n"
+
+	started, err := imports.StartImport(strings.NewReader(file), "products.csv", service.FormatCSV, false, 1)
+	if err != nil {
+		t.Fatalf("StartImport: %v", err)
+	}
+	imports.Wait()
+
+	job, err := imports.FindImportJob(started.Id)
+	if err != nil {
+		t.Fatalf("FindImportJob: %v", err)
+	}
+	if job.CreatedRows != 1 || len(job.Errors) != 1 {
+		t.Fatalf("job = %+v, want one row created and one rejected", job)
+	}
+	if job.Errors[0].Message != "internal server error" {
+		t.Errorf("rejected row message = %q, want the generic message", job.Errors[0].Message)
+	}
+}
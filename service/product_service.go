@@ -1,53 +1,122 @@
 package service
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"learn/common"
 	"learn/model"
 	"learn/repository"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
 )
 
 type ProductService interface {
 	// ADMIN
-	AddProduct(req model.ProductReq) (model.ProductRes, error)
-	FindProductById(productId int) (model.ProductRes, error)
-	UpdateProduct(req model.ProductReq, productId int) (model.ProductRes, error)
-	DeleteProduct(productId int) (model.MessageResponse, error)
-
-	FindAllProductImagesByProductId(productId int) (model.ProductImagesRes, error)
-	UploadProductImages(req model.ProductImagesUploadReq, productId int, productName string) (model.MessageResponse, error)
-	DeleteProductImageId(prodImgId int, roductId int) (model.MessageResponse, error)
+	AddProduct(ctx context.Context, req model.ProductReq, userId int) (model.ProductRes, error)
+	FindProductById(ctx context.Context, productId int, currency string) (model.ProductRes, error)
+	FindProductBySku(ctx context.Context, sku string) (model.ProductRes, error)
+	FindProductByBarcode(ctx context.Context, barcode string) (model.ProductRes, error)
+	BarcodeLabel(ctx context.Context, productId int) ([]byte, error)
+	UpdateProduct(ctx context.Context, req model.ProductReq, productId int, userId int) (model.ProductRes, error)
+	DeleteProduct(ctx context.Context, productId int) (model.MessageResponse, error)
+	FindTrashedProducts(ctx context.Context) ([]model.ProductRes, error)
+	RestoreProduct(ctx context.Context, productId int) (model.MessageResponse, error)
+
+	FindAllProductImagesByProductId(ctx context.Context, productId int) (model.ProductImagesRes, error)
+	UploadProductImages(ctx context.Context, req model.ProductImagesUploadReq, productId int, ext string, data []byte) (model.ProductImageRes, error)
+	UpdateProductImage(ctx context.Context, req model.ProductImageReq, prodImgId int, productId int) (model.ProductImageRes, error)
+	ReorderProductImages(ctx context.Context, req model.ProductImageOrderReq, productId int) (model.ProductImagesRes, error)
+	DeleteProductImageId(ctx context.Context, prodImgId int, roductId int) (model.MessageResponse, error)
 
 	// USER
-	FindAllProduct() ([]model.ProductRes, error)
+	FindAllProduct(ctx context.Context, currency string) ([]model.ProductRes, error)
+	FindPublicProductById(ctx context.Context, productId int, currency string) (model.ProductDetailRes, error)
+	FindProductBySlug(ctx context.Context, slug string, currency string) (model.ProductDetailRes, error)
+	Sitemap(ctx context.Context, baseURL string) (model.SitemapRes, error)
+
+	// SYSTEM
+	PurgeTrash(ctx context.Context, retention time.Duration) (int64, error)
 }
 
 type productService struct {
-	Repo repository.ProductRepository
+	Repo      repository.ProductRepository
+	TaxRepo   repository.TaxRepository
+	RateRepo  repository.ExchangeRateRepository
+	Inventory InventoryService
+	Images    ImageStore
+
+	// fileMu keeps image file references and the files themselves in step,
+	// so a purge cannot remove a file an upload is just taking again. It
+	// only covers this process: with several instances sharing one image
+	// store, a purge in one can still remove a file an upload in another
+	// has just saved, so the trash purger should run on one instance only.
+	fileMu sync.Mutex
 }
 
-func NewProductService(repo repository.ProductRepository) ProductService {
+func NewProductService(repo repository.ProductRepository, taxRepo repository.TaxRepository, rateRepo repository.ExchangeRateRepository, inventory InventoryService, images ImageStore) ProductService {
 	return &productService{
-		Repo: repo,
+		Repo:      repo,
+		TaxRepo:   taxRepo,
+		RateRepo:  rateRepo,
+		Inventory: inventory,
+		Images:    images,
 	}
 }
 
 var (
-	emptyAddProductRes = model.ProductRes{}
-	empryProductsRes   = []model.ProductRes{}
-	emptyMessageRes    = model.MessageResponse{}
-	emptyProductImages = model.ProductImagesRes{}
+	emptyAddProductRes   = model.ProductRes{}
+	empryProductsRes     = []model.ProductRes{}
+	emptyMessageRes      = model.MessageResponse{}
+	emptyProductImages   = model.ProductImagesRes{}
+	emptyProductImageRes = model.ProductImageRes{}
+
+	emptyProductDetailRes = model.ProductDetailRes{}
 )
 
-// AddProduct implements ProductService
-func (s *productService) AddProduct(req model.ProductReq) (model.ProductRes, error) {
+// AddProduct implements ProductService. The initial stock is booked in the
+// ledger as a restock by userId.
+func (s *productService) AddProduct(ctx context.Context, req model.ProductReq, userId int) (model.ProductRes, error) {
 	dbProduct := model.Product{}
+	dbProduct.Sku = strings.TrimSpace(req.Sku)
+	dbProduct.Barcode = req.Barcode
 	dbProduct.Name = req.Name
+	dbProduct.MetaTitle = req.MetaTitle
+	dbProduct.MetaDescription = req.MetaDescription
 	dbProduct.Description = req.Description
 	dbProduct.Quantity = req.Quantity
+	dbProduct.LowStockThreshold = req.LowStockThreshold
 	dbProduct.Price = req.Price
+	dbProduct.TaxInclusive = req.TaxInclusive
+
+	taxClass, err := s.findTaxClass(req.TaxClassId)
+	if err != nil {
+		return emptyAddProductRes, err
+	}
+	dbProduct.SetTaxClass(taxClass)
 
-	product, err := s.Repo.CreateProduct(dbProduct)
+	err = s.checkSku(ctx, dbProduct.Sku, 0)
+	if err != nil {
+		return emptyAddProductRes, err
+	}
+
+	err = s.checkBarcode(ctx, dbProduct.Barcode, 0)
+	if err != nil {
+		return emptyAddProductRes, err
+	}
+
+	dbProduct.Slug, err = s.newSlug(ctx, req.Slug, req.Name, 0)
+	if err != nil {
+		return emptyAddProductRes, err
+	}
+
+	stock := model.InventoryMovement{Type: model.MovementRestock, Reason: "initial stock", UserId: userId}
+
+	product, _, err := s.Repo.CreateProduct(ctx, dbProduct, stock)
 	if err != nil {
 		return emptyAddProductRes, fmt.Errorf("CreateProduct call failed: %w", err)
 	}
@@ -58,84 +127,290 @@ func (s *productService) AddProduct(req model.ProductReq) (model.ProductRes, err
 }
 
 // FindProductById implements ProductService
-func (s *productService) FindProductById(productId int) (model.ProductRes, error) {
-	product, err := s.Repo.FindProductById(productId)
+func (s *productService) FindProductById(ctx context.Context, productId int, currency string) (model.ProductRes, error) {
+	product, err := s.Repo.FindProductById(ctx, productId)
 	if err != nil {
 		return emptyAddProductRes, fmt.Errorf("FindProductById call failed: %w", err)
 	}
 
-	if product.Id == 0 {
-		return emptyAddProductRes, fmt.Errorf("product %d : %w", productId, common.ErrNotFound)
-	}
-
-	productImages, err := s.Repo.FindAllProductImagesByProductId(productId)
+	productImages, err := s.Repo.FindAllProductImagesByProductId(ctx, productId)
 	if err != nil {
 		return emptyAddProductRes, fmt.Errorf("FindAllProductImagesByProductId call failed: %w", err)
 	}
 
 	product.ProductImages = productImages
 
-	response := model.ProductFormatRes(product)
-	return response, nil
+	response := []model.ProductRes{model.ProductFormatRes(product)}
+
+	err = s.setDisplayPrices(response, currency)
+	if err != nil {
+		return emptyAddProductRes, err
+	}
+
+	return response[0], nil
 }
 
-// UpdateProductById implements ProductService
-func (s *productService) UpdateProduct(req model.ProductReq, productId int) (model.ProductRes, error) {
-	product, err := s.Repo.FindProductById(productId)
+// FindProductBySku implements ProductService
+func (s *productService) FindProductBySku(ctx context.Context, sku string) (model.ProductRes, error) {
+	product, err := s.Repo.FindProductBySku(ctx, sku)
 	if err != nil {
-		return emptyAddProductRes, fmt.Errorf("FindProductById call failed: %w", err)
+		return emptyAddProductRes, fmt.Errorf("FindProductBySku call failed: %w", err)
+	}
+
+	if product.DeletedAt.Valid {
+		return emptyAddProductRes, fmt.Errorf("product sku %s : %w", sku, common.ErrNotFound)
+	}
+
+	return s.FindProductById(ctx, product.Id, "")
+}
+
+// FindProductByBarcode implements ProductService
+func (s *productService) FindProductByBarcode(ctx context.Context, barcode string) (model.ProductRes, error) {
+	product, err := s.Repo.FindProductByBarcode(ctx, barcode)
+	if err != nil {
+		return emptyAddProductRes, fmt.Errorf("FindProductByBarcode call failed: %w", err)
+	}
+
+	if product.DeletedAt.Valid {
+		return emptyAddProductRes, fmt.Errorf("product barcode %s : %w", barcode, common.ErrNotFound)
 	}
 
-	if product.Id == 0 {
-		return emptyAddProductRes, fmt.Errorf("product %d : %w", productId, common.ErrNotFound)
+	return s.FindProductById(ctx, product.Id, "")
+}
+
+// BarcodeLabel implements ProductService. The label shows the product's
+// EAN/UPC barcode, or its SKU as Code 128 when it has no barcode.
+func (s *productService) BarcodeLabel(ctx context.Context, productId int) ([]byte, error) {
+	product, err := s.Repo.FindProductById(ctx, productId)
+	if err != nil {
+		return nil, fmt.Errorf("FindProductById call failed: %w", err)
 	}
 
-	productImages, err := s.Repo.FindAllProductImagesByProductId(productId)
+	if product.Barcode == "" && product.Sku == "" {
+		return nil, fmt.Errorf("product %d barcode : %w", productId, common.ErrNotFound)
+	}
+
+	label, err := renderBarcodeLabel(product.Barcode, product.Sku, product.Name)
+	if err != nil {
+		return nil, fmt.Errorf("product %d barcode label: %w", productId, err)
+	}
+
+	return label, nil
+}
+
+// UpdateProductById implements ProductService. A changed quantity is
+// booked in the ledger as an adjustment by userId, together with the
+// update.
+func (s *productService) UpdateProduct(ctx context.Context, req model.ProductReq, productId int, userId int) (model.ProductRes, error) {
+	product, err := s.Repo.FindProductById(ctx, productId)
+	if err != nil {
+		return emptyAddProductRes, fmt.Errorf("FindProductById call failed: %w", err)
+	}
+
+	productImages, err := s.Repo.FindAllProductImagesByProductId(ctx, productId)
 	if err != nil {
 		return emptyAddProductRes, fmt.Errorf("FindAllProductImagesByProductId call failed: %w", err)
 	}
 
+	product.Sku = strings.TrimSpace(req.Sku)
+	product.Barcode = req.Barcode
 	product.Name = req.Name
+	product.MetaTitle = req.MetaTitle
+	product.MetaDescription = req.MetaDescription
 	product.Description = req.Description
 	product.Quantity = req.Quantity
+	product.LowStockThreshold = req.LowStockThreshold
 	product.Price = req.Price
+	product.TaxInclusive = req.TaxInclusive
 	product.ProductImages = productImages
 
-	productUpdate, err := s.Repo.UpdateProduct(product)
+	taxClass, err := s.findTaxClass(req.TaxClassId)
+	if err != nil {
+		return emptyAddProductRes, err
+	}
+	product.SetTaxClass(taxClass)
+
+	err = s.checkSku(ctx, product.Sku, productId)
+	if err != nil {
+		return emptyAddProductRes, err
+	}
+
+	err = s.checkBarcode(ctx, product.Barcode, productId)
+	if err != nil {
+		return emptyAddProductRes, err
+	}
+
+	if req.Slug != "" && model.Slugify(req.Slug) != product.Slug {
+		product.Slug, err = s.newSlug(ctx, req.Slug, req.Name, productId)
+		if err != nil {
+			return emptyAddProductRes, err
+		}
+	}
+
+	stock := model.InventoryMovement{Type: model.MovementAdjustment, Reason: "product update", UserId: userId}
+
+	productUpdate, movement, err := s.Repo.UpdateProduct(ctx, product, stock)
 	if err != nil {
 		return emptyAddProductRes, fmt.Errorf("UpdateProduct call failed: %w", err)
 	}
 
+	s.Inventory.NotifyMovement(movement)
+
 	response := model.ProductFormatRes(productUpdate)
 	return response, nil
 }
 
 // DeleteProduct implements ProductService
-func (s *productService) DeleteProduct(productId int) (model.MessageResponse, error) {
-	product, err := s.Repo.FindProductById(productId)
+func (s *productService) DeleteProduct(ctx context.Context, productId int) (model.MessageResponse, error) {
+	err := s.Repo.DeleteProduct(ctx, productId)
 	if err != nil {
-		return emptyMessageRes, fmt.Errorf("FindProductById call failed: %w", err)
+		return emptyMessageRes, fmt.Errorf("DeleteProduct call failed: %w", err)
 	}
 
-	if product.Id == 0 {
-		return emptyMessageRes, fmt.Errorf("product %d : %w", productId, common.ErrNotFound)
+	response := model.MessageResponse{
+		Message: fmt.Sprintf("product id %d successfully deleted", productId),
 	}
 
-	err = s.Repo.DeleteProduct(productId)
+	return response, nil
+}
+
+// FindPublicProductById implements ProductService
+func (s *productService) FindPublicProductById(ctx context.Context, productId int, currency string) (model.ProductDetailRes, error) {
+	product, err := s.Repo.FindProductDetailById(ctx, productId)
 	if err != nil {
-		return emptyMessageRes, fmt.Errorf("DeleteProduct call failed: %w", err)
+		return emptyProductDetailRes, fmt.Errorf("FindProductDetailById call failed: %w", err)
+	}
+
+	return s.productDetail(ctx, product, currency)
+}
+
+// FindProductBySlug implements ProductService. An old slug resolves to the
+// product's current one, so a response whose Slug differs from the
+// requested slug should be answered with a redirect.
+func (s *productService) FindProductBySlug(ctx context.Context, slug string, currency string) (model.ProductDetailRes, error) {
+	product, err := s.Repo.FindProductBySlug(ctx, slug)
+	if errors.Is(err, common.ErrNotFound) {
+		history, err := s.Repo.FindSlugHistory(ctx, slug)
+		if err != nil {
+			return emptyProductDetailRes, fmt.Errorf("FindSlugHistory call failed: %w", err)
+		}
+
+		product, err = s.Repo.FindProductById(ctx, history.ProductId)
+		if err != nil {
+			return emptyProductDetailRes, fmt.Errorf("FindProductById call failed: %w", err)
+		}
+
+		return model.ProductDetailRes{ProductRes: model.ProductFormatRes(product)}, nil
+	}
+	if err != nil {
+		return emptyProductDetailRes, fmt.Errorf("FindProductBySlug call failed: %w", err)
+	}
+
+	return s.productDetail(ctx, product, currency)
+}
+
+// productDetail formats a product for the public detail page together with
+// related products, all priced in currency when one is asked for.
+func (s *productService) productDetail(ctx context.Context, product model.Product, currency string) (model.ProductDetailRes, error) {
+	related, err := s.Repo.FindRelatedProducts(ctx, product, 4)
+	if err != nil {
+		return emptyProductDetailRes, fmt.Errorf("FindRelatedProducts call failed: %w", err)
+	}
+
+	products := append([]model.ProductRes{model.ProductFormatRes(product)}, model.ProductsFormatRes(related)...)
+
+	err = s.setDisplayPrices(products, currency)
+	if err != nil {
+		return emptyProductDetailRes, err
+	}
+
+	response := model.ProductDetailRes{
+		ProductRes:      products[0],
+		RelatedProducts: products[1:],
+	}
+
+	return response, nil
+}
+
+// Sitemap implements ProductService
+func (s *productService) Sitemap(ctx context.Context, baseURL string) (model.SitemapRes, error) {
+	products, err := s.Repo.FindAllProduct(ctx)
+	if err != nil {
+		return model.SitemapRes{}, fmt.Errorf("FindAllProduct call failed: %w", err)
+	}
+
+	return model.SitemapFormatRes(baseURL, products), nil
+}
+
+// FindTrashedProducts implements ProductService
+func (s *productService) FindTrashedProducts(ctx context.Context) ([]model.ProductRes, error) {
+	products, err := s.Repo.FindTrashedProducts(ctx)
+	if err != nil {
+		return empryProductsRes, fmt.Errorf("FindTrashedProducts call failed: %w", err)
+	}
+
+	return model.ProductsFormatRes(products), nil
+}
+
+// RestoreProduct implements ProductService
+func (s *productService) RestoreProduct(ctx context.Context, productId int) (model.MessageResponse, error) {
+	err := s.Repo.RestoreProduct(ctx, productId)
+	if err != nil {
+		return emptyMessageRes, fmt.Errorf("RestoreProduct call failed: %w", err)
 	}
 
 	response := model.MessageResponse{
-		Message: fmt.Sprintf("product id %d successfully deleted", productId),
+		Message: fmt.Sprintf("product id %d successfully restored", productId),
 	}
 
 	return response, nil
 }
 
+// PurgeTrash implements ProductService
+func (s *productService) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+
+	purged, unusedFiles, err := s.Repo.PurgeTrashedProducts(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("PurgeTrashedProducts call failed: %w", err)
+	}
+
+	for _, fileName := range unusedFiles {
+		err = s.Images.Remove(fileName)
+		if err != nil {
+			slog.ErrorContext(ctx, "remove image file failed", "file", fileName, "error", err)
+		}
+	}
+
+	return purged, nil
+}
+
+// RunTrashPurger purges products that have been in the trash longer than
+// retention, every interval until ctx is done.
+func RunTrashPurger(ctx context.Context, srv ProductService, retention time.Duration, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := srv.PurgeTrash(ctx, retention)
+		if err != nil {
+			slog.ErrorContext(ctx, "purge product trash failed", "error", err)
+		} else if purged > 0 {
+			slog.InfoContext(ctx, "purged products from trash", "count", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 // FindAllProductImagesByProductId implements ProductService
-func (s *productService) FindAllProductImagesByProductId(productId int) (model.ProductImagesRes, error) {
-	productImages, err := s.Repo.FindAllProductImagesByProductId(productId)
+func (s *productService) FindAllProductImagesByProductId(ctx context.Context, productId int) (model.ProductImagesRes, error) {
+	productImages, err := s.Repo.FindAllProductImagesByProductId(ctx, productId)
 	if err != nil {
 		return emptyProductImages, fmt.Errorf("FindAllProductImagesByProductId call failed: %w", err)
 	}
@@ -147,53 +422,126 @@ func (s *productService) FindAllProductImagesByProductId(productId int) (model.P
 	return response, nil
 }
 
-// UploadProductImages implements ProductService
-func (s *productService) UploadProductImages(req model.ProductImagesUploadReq, productId int, productName string) (model.MessageResponse, error) {
+// UploadProductImages implements ProductService. Image files are stored
+// once under their SHA-256 content hash and shared by every image that
+// uses the same bytes. The file is saved before anything is written to the
+// database, so a failed save leaves the product's images as they were. If
+// the database write fails instead, the file stays behind unreferenced;
+// saving is idempotent, so a retry takes it over, and it is harmless
+// otherwise.
+func (s *productService) UploadProductImages(ctx context.Context, req model.ProductImagesUploadReq, productId int, ext string, data []byte) (model.ProductImageRes, error) {
 	productImage := model.ProductImage{}
 
-	prodImages, err := s.Repo.FindAllProductImagesByProductId(productId)
+	prodImages, err := s.Repo.FindAllProductImagesByProductId(ctx, productId)
 	if err != nil {
-		return emptyMessageRes, fmt.Errorf("FindAllProductImagesByProductId call failed: %w", err)
+		return emptyProductImageRes, fmt.Errorf("FindAllProductImagesByProductId call failed: %w", err)
 	}
 
 	isPrimary := "no"
 	if len(prodImages) == 0 && req.IsPrimary == "no" {
-		return emptyMessageRes, common.ErrMustHavePrimary
-	} else if len(prodImages) > 0 && req.IsPrimary == "yes" {
+		return emptyProductImageRes, common.ErrMustHavePrimary
+	} else if req.IsPrimary == "yes" {
 		isPrimary = "yes"
+	}
 
-		_, err := s.Repo.MarkAllProductImagesNonPrimary(productId)
-		if err != nil {
-			return emptyMessageRes, fmt.Errorf("MarkAllProductImagesNonPrimary call failed: %w", err)
+	position := 0
+	for _, prodImage := range prodImages {
+		if prodImage.Position > position {
+			position = prodImage.Position
 		}
-	} else if req.IsPrimary == "yes" {
-		isPrimary = "yes"
 	}
 
+	hash := sha256.Sum256(data)
+	imageFile := model.ImageFile{
+		Hash: hex.EncodeToString(hash[:]),
+		Size: int64(len(data)),
+	}
+	imageFile.FileName = imageFile.Hash + ext
+
 	productImage.ProductId = productId
-	productImage.FileName = productName
+	productImage.FileName = "pringgodigdo.com/" + imageFile.FileName
+	productImage.ContentHash = imageFile.Hash
 	productImage.IsPrimary = isPrimary
+	productImage.Position = position + 1
+	productImage.AltText = req.AltText
+
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
 
-	_, err = s.Repo.CreateProductImages(productImage)
+	err = s.Images.Save(imageFile.FileName, data)
 	if err != nil {
-		return emptyMessageRes, fmt.Errorf("CreateProductImages call failed: %w", err)
+		return emptyProductImageRes, fmt.Errorf("image file %s: %w", imageFile.FileName, err)
 	}
 
-	response := model.MessageResponse{
-		Message: "upload product image successfully",
+	productImage, err = s.Repo.CreateProductImages(ctx, productImage, imageFile)
+	if err != nil {
+		return emptyProductImageRes, fmt.Errorf("CreateProductImages call failed: %w", err)
 	}
 
-	return response, nil
+	return model.ProductImageFormatRes(productImage), nil
+}
+
+// UpdateProductImage implements ProductService
+func (s *productService) UpdateProductImage(ctx context.Context, req model.ProductImageReq, prodImgId int, productId int) (model.ProductImageRes, error) {
+	productImage, err := s.Repo.FindProductImageById(ctx, prodImgId)
+	if err != nil {
+		return emptyProductImageRes, fmt.Errorf("FindProductImageById call failed: %w", err)
+	}
+
+	if productImage.ProductId != productId {
+		return emptyProductImageRes, fmt.Errorf("product image %d : %w", prodImgId, common.ErrNotFound)
+	}
+
+	productImage.AltText = req.AltText
+
+	productImage, err = s.Repo.UpdateProductImageById(ctx, productImage)
+	if err != nil {
+		return emptyProductImageRes, fmt.Errorf("UpdateProductImageById call failed: %w", err)
+	}
+
+	return model.ProductImageFormatRes(productImage), nil
+}
+
+// ReorderProductImages implements ProductService. The request must list
+// every image of the product exactly once; the primary image is still
+// returned first whatever its position.
+func (s *productService) ReorderProductImages(ctx context.Context, req model.ProductImageOrderReq, productId int) (model.ProductImagesRes, error) {
+	productImages, err := s.Repo.FindAllProductImagesByProductId(ctx, productId)
+	if err != nil {
+		return emptyProductImages, fmt.Errorf("FindAllProductImagesByProductId call failed: %w", err)
+	}
+
+	if len(req.ImageIds) != len(productImages) {
+		return emptyProductImages, fmt.Errorf("product %d image order : %w", productId, common.ErrNotMatch)
+	}
+
+	imageIds := map[int]bool{}
+	for _, productImage := range productImages {
+		imageIds[productImage.Id] = true
+	}
+
+	for _, imageId := range req.ImageIds {
+		if !imageIds[imageId] {
+			return emptyProductImages, fmt.Errorf("product %d image %d : %w", productId, imageId, common.ErrNotMatch)
+		}
+	}
+
+	err = s.Repo.ReorderProductImages(ctx, productId, req.ImageIds)
+	if err != nil {
+		return emptyProductImages, fmt.Errorf("ReorderProductImages call failed: %w", err)
+	}
+
+	return s.FindAllProductImagesByProductId(ctx, productId)
 }
 
 // DeleteProductImageId implements ProductService
-func (s *productService) DeleteProductImageId(prodImgId int, productId int) (model.MessageResponse, error) {
-	err := s.Repo.DeleteProductImageById(prodImgId)
+func (s *productService) DeleteProductImageId(ctx context.Context, prodImgId int, productId int) (model.MessageResponse, error) {
+	err := s.Repo.DeleteProductImageById(ctx, prodImgId)
 	if err != nil {
 		return emptyMessageRes, fmt.Errorf("DeleteProductImageById call failed: %w", err)
 	}
 
-	productImages, err := s.Repo.FindAllProductImagesByProductId(productId)
+	productImages, err := s.Repo.FindAllProductImagesByProductId(ctx, productId)
 	if err != nil {
 		return emptyMessageRes, fmt.Errorf("FindAllProductImagesByProductId call failed: %w", err)
 	}
@@ -203,7 +551,7 @@ func (s *productService) DeleteProductImageId(prodImgId int, productId int) (mod
 			prodImg := productImages[0]
 			prodImg.IsPrimary = "yes"
 
-			_, err := s.Repo.UpdateProductImageById(prodImg)
+			_, err := s.Repo.UpdateProductImageById(ctx, prodImg)
 			if err != nil {
 				return emptyMessageRes, fmt.Errorf("UpdateProductImageById call failed: %w", err)
 			}
@@ -217,15 +565,149 @@ func (s *productService) DeleteProductImageId(prodImgId int, productId int) (mod
 	return response, nil
 }
 
+// newSlug returns a free slug for a product. An explicitly requested slug
+// must be free; one generated from the name gets a numeric suffix until it
+// is.
+func (s *productService) newSlug(ctx context.Context, requested string, name string, productId int) (string, error) {
+	if requested != "" {
+		slug := model.Slugify(requested)
+
+		taken, err := s.Repo.IsSlugTaken(ctx, slug, productId)
+		if err != nil {
+			return "", fmt.Errorf("IsSlugTaken call failed: %w", err)
+		}
+
+		if taken {
+			return "", fmt.Errorf("product slug %s : %w", slug, common.ErrExists)
+		}
+
+		return slug, nil
+	}
+
+	base := model.Slugify(name)
+	slug := base
+
+	for i := 2; ; i++ {
+		taken, err := s.Repo.IsSlugTaken(ctx, slug, productId)
+		if err != nil {
+			return "", fmt.Errorf("IsSlugTaken call failed: %w", err)
+		}
+
+		if !taken {
+			return slug, nil
+		}
+
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// checkSku makes sure no other product, trashed or not, uses the SKU.
+func (s *productService) checkSku(ctx context.Context, sku string, productId int) error {
+	if sku == "" {
+		return nil
+	}
+
+	product, err := s.Repo.FindProductBySku(ctx, sku)
+	if errors.Is(err, common.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("FindProductBySku call failed: %w", err)
+	}
+
+	if product.Id != productId {
+		return fmt.Errorf("product sku %s : %w", sku, common.ErrExists)
+	}
+
+	return nil
+}
+
+// checkBarcode makes sure the barcode has a valid check digit and no other
+// product, trashed or not, uses it.
+func (s *productService) checkBarcode(ctx context.Context, barcode string, productId int) error {
+	if barcode == "" {
+		return nil
+	}
+
+	if !model.ValidBarcode(barcode) {
+		return fmt.Errorf("product barcode %s check digit : %w", barcode, common.ErrNotMatch)
+	}
+
+	product, err := s.Repo.FindProductByBarcode(ctx, barcode)
+	if errors.Is(err, common.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("FindProductByBarcode call failed: %w", err)
+	}
+
+	if product.Id != productId {
+		return fmt.Errorf("product barcode %s : %w", barcode, common.ErrExists)
+	}
+
+	return nil
+}
+
+// findTaxClass returns the tax class a product refers to. A zero id means
+// the product is not taxed.
+func (s *productService) findTaxClass(taxClassId int) (model.TaxClass, error) {
+	if taxClassId == 0 {
+		return model.TaxClass{}, nil
+	}
+
+	taxClass, err := s.TaxRepo.FindTaxClassById(taxClassId)
+	if err != nil {
+		return model.TaxClass{}, fmt.Errorf("FindTaxClassById call failed: %w", err)
+	}
+
+	return taxClass, nil
+}
+
+// setDisplayPrices fills in the price converted to currency. Orders are
+// still settled in the product's own price, so an empty currency leaves the
+// responses untouched.
+func (s *productService) setDisplayPrices(products []model.ProductRes, currency string) error {
+	if currency == "" {
+		return nil
+	}
+	currency = strings.ToUpper(currency)
+
+	rates, err := s.RateRepo.FindAllRates()
+	if err != nil {
+		return fmt.Errorf("FindAllRates call failed: %w", err)
+	}
+
+	ratesByCurrency := map[string]model.ExchangeRate{}
+	for _, rate := range rates {
+		ratesByCurrency[rate.Currency] = rate
+	}
+
+	for i := range products {
+		displayPrice, err := model.ConvertPrice(products[i].Price, currency, ratesByCurrency)
+		if err != nil {
+			return fmt.Errorf("ConvertPrice call failed: %w", err)
+		}
+
+		products[i].DisplayPrice = &displayPrice
+	}
+
+	return nil
+}
+
 // / USER
 // FindAllProduct implements ProductService
-func (s *productService) FindAllProduct() ([]model.ProductRes, error) {
-	products, err := s.Repo.FindAllProduct()
+func (s *productService) FindAllProduct(ctx context.Context, currency string) ([]model.ProductRes, error) {
+	products, err := s.Repo.FindAllProduct(ctx)
 	if err != nil {
-		return empryProductsRes, fmt.Errorf("product : %w", common.ErrNotFound)
+		return empryProductsRes, fmt.Errorf("FindAllProduct call failed: %w", err)
 	}
 
 	response := model.ProductsFormatRes(products)
 
+	err = s.setDisplayPrices(response, currency)
+	if err != nil {
+		return empryProductsRes, err
+	}
+
 	return response, nil
 }
@@ -0,0 +1,266 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"learn/common"
+	"learn/model"
+	"learn/repository/repotest"
+	"learn/service"
+	"testing"
+	"time"
+)
+
+type productFixture struct {
+	srv       service.ProductService
+	repo      *repotest.ProductRepository
+	inventory *fakeInventory
+	images    *fakeImageStore
+}
+
+func newProductFixture(products ...model.Product) productFixture {
+	f := productFixture{
+		repo:      repotest.NewProductRepository(products...),
+		inventory: newFakeInventory(),
+		images:    newFakeImageStore(),
+	}
+	f.srv = service.NewProductService(f.repo, &fakeTaxRepository{}, &fakeRateRepository{}, f.inventory, f.images)
+
+	return f
+}
+
+func productReq(name string) model.ProductReq {
+	return model.ProductReq{
+		Name:        name,
+		Description: "Fresh from the roastery",
+		Quantity:    5,
+		Price:       model.Money{Amount: 120000, Currency: model.BaseCurrency},
+	}
+}
+
+func TestAddProduct(t *testing.T) {
+	ctx := context.Background()
+	taken := repotest.NewProduct(func(p *model.Product) { p.Slug = "kopi-gayo" })
+	f := newProductFixture(taken)
+
+	res, err := f.srv.AddProduct(ctx, productReq("Kopi Gayo"), 1)
+	if err != nil {
+		t.Fatalf("AddProduct: %v", err)
+	}
+	if res.Slug != "kopi-gayo-2" {
+		t.Errorf("Slug = %q, want kopi-gayo-2", res.Slug)
+	}
+	if res.Quantity != 5 {
+		t.Errorf("Quantity = %d, want the initial stock 5", res.Quantity)
+	}
+
+	// A numeric slug would be taken for a product id.
+	res, err = f.srv.AddProduct(ctx, productReq("1984"), 1)
+	if err != nil {
+		t.Fatalf("AddProduct: %v", err)
+	}
+	if res.Slug != "product-1984" {
+		t.Errorf("Slug = %q, want product-1984", res.Slug)
+	}
+
+	tests := []struct {
+		name    string
+		req     func(*model.ProductReq)
+		wantErr error
+	}{
+		{"taken sku", func(req *model.ProductReq) { req.Sku = taken.Sku }, common.ErrExists},
+		{"taken slug", func(req *model.ProductReq) { req.Slug = "Kopi Gayo" }, common.ErrExists},
+		{"bad barcode check digit", func(req *model.ProductReq) { req.Barcode = "4006381333932" }, common.ErrNotMatch},
+		{"missing tax class", func(req *model.ProductReq) { req.TaxClassId = 9 }, common.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := productReq("Kopi Toraja")
+			tt.req(&req)
+
+			_, err := f.srv.AddProduct(ctx, req, 1)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("AddProduct error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFindAllProductKeepsErrors(t *testing.T) {
+	f := newProductFixture()
+	srv := service.NewProductService(brokenProductRepository{f.repo}, &fakeTaxRepository{}, &fakeRateRepository{}, f.inventory, f.images)
+
+	_, err := srv.FindAllProduct(context.Background(), "")
+	if err == nil || errors.Is(err, common.ErrNotFound) || common.AsError(err).Kind != common.KindInternal {
+		t.Errorf("FindAllProduct error = %v, want an internal error", err)
+	}
+}
+
+func TestUpdateProductStock(t *testing.T) {
+	ctx := context.Background()
+	other := repotest.NewProduct()
+	f := newProductFixture(other)
+
+	product, err := f.srv.AddProduct(ctx, productReq("Kopi Gayo"), 3)
+	if err != nil {
+		t.Fatalf("AddProduct: %v", err)
+	}
+
+	req := productReq("Kopi Gayo")
+	req.Sku = other.Sku
+	req.Quantity = 8
+	_, err = f.srv.UpdateProduct(ctx, req, product.Id, 4)
+	if !errors.Is(err, common.ErrExists) {
+		t.Fatalf("UpdateProduct with taken sku error = %v, want ErrExists", err)
+	}
+	if movements := f.repo.Movements(product.Id); len(movements) != 1 {
+		t.Fatalf("failed update booked stock: %+v", movements)
+	}
+
+	req.Sku = ""
+	res, err := f.srv.UpdateProduct(ctx, req, product.Id, 4)
+	if err != nil {
+		t.Fatalf("UpdateProduct: %v", err)
+	}
+	if res.Quantity != 8 {
+		t.Errorf("Quantity = %d, want 8", res.Quantity)
+	}
+
+	movements := f.repo.Movements(product.Id)
+	if len(movements) != 2 {
+		t.Fatalf("movements = %+v, want the initial stock and the update", movements)
+	}
+	if movements[0].UserId != 3 || movements[0].Type != model.MovementRestock || movements[0].Quantity != 5 {
+		t.Errorf("initial stock = %+v, want a restock of 5 by user 3", movements[0])
+	}
+	if movements[1].UserId != 4 || movements[1].Type != model.MovementAdjustment || movements[1].Quantity != 3 {
+		t.Errorf("update = %+v, want an adjustment of 3 by user 4", movements[1])
+	}
+	if len(f.inventory.notified) != 1 || f.inventory.notified[0].Id != movements[1].Id {
+		t.Errorf("notified = %+v, want the update's movement", f.inventory.notified)
+	}
+}
+
+func TestFindProductBySlugFollowsRenames(t *testing.T) {
+	ctx := context.Background()
+	f := newProductFixture()
+
+	product, err := f.srv.AddProduct(ctx, productReq("Kopi Gayo"), 1)
+	if err != nil {
+		t.Fatalf("AddProduct: %v", err)
+	}
+
+	req := productReq("Kopi Gayo")
+	req.Slug = "kopi-gayo-wine"
+	_, err = f.srv.UpdateProduct(ctx, req, product.Id, 1)
+	if err != nil {
+		t.Fatalf("UpdateProduct: %v", err)
+	}
+
+	res, err := f.srv.FindProductBySlug(ctx, "kopi-gayo", "")
+	if err != nil {
+		t.Fatalf("FindProductBySlug old slug: %v", err)
+	}
+	if res.Slug != "kopi-gayo-wine" {
+		t.Errorf("old slug resolved to %q, want kopi-gayo-wine", res.Slug)
+	}
+
+	_, err = f.srv.FindProductBySlug(ctx, "kopi-bali", "")
+	if !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("FindProductBySlug unknown slug error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDeleteAndRestoreProduct(t *testing.T) {
+	ctx := context.Background()
+	product := repotest.NewProduct(func(p *model.Product) { p.Id = 1 })
+	f := newProductFixture(product)
+
+	_, err := f.srv.DeleteProduct(ctx, product.Id)
+	if err != nil {
+		t.Fatalf("DeleteProduct: %v", err)
+	}
+
+	_, err = f.srv.FindProductById(ctx, product.Id, "")
+	if !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("FindProductById of trashed product error = %v, want ErrNotFound", err)
+	}
+
+	_, err = f.srv.DeleteProduct(ctx, product.Id)
+	if !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("DeleteProduct twice error = %v, want ErrNotFound", err)
+	}
+
+	_, err = f.srv.RestoreProduct(ctx, product.Id)
+	if err != nil {
+		t.Fatalf("RestoreProduct: %v", err)
+	}
+
+	_, err = f.srv.FindProductById(ctx, product.Id, "")
+	if err != nil {
+		t.Errorf("FindProductById after restore: %v", err)
+	}
+}
+
+func TestUploadProductImageWithoutStorage(t *testing.T) {
+	ctx := context.Background()
+	product := repotest.NewProduct(func(p *model.Product) { p.Id = 1 })
+	f := newProductFixture(product)
+
+	primary, err := f.srv.UploadProductImages(ctx, model.ProductImagesUploadReq{IsPrimary: "yes"}, product.Id, ".png", []byte("first"))
+	if err != nil {
+		t.Fatalf("UploadProductImages: %v", err)
+	}
+
+	f.images.err = errors.New("no space left on device")
+	_, err = f.srv.UploadProductImages(ctx, model.ProductImagesUploadReq{IsPrimary: "yes"}, product.Id, ".png", []byte("second"))
+	if err == nil {
+		t.Fatal("UploadProductImages without storage succeeded")
+	}
+
+	images, err := f.repo.FindAllProductImagesByProductId(ctx, product.Id)
+	if err != nil {
+		t.Fatalf("FindAllProductImagesByProductId: %v", err)
+	}
+	if len(images) != 1 || images[0].Id != primary.Id || images[0].IsPrimary != "yes" {
+		t.Errorf("images after failed upload = %+v, want only the primary from before", images)
+	}
+}
+
+func TestPurgeTrashRemovesUnusedFiles(t *testing.T) {
+	ctx := context.Background()
+	product := repotest.NewProduct(func(p *model.Product) { p.Id = 1 })
+	f := newProductFixture(product)
+
+	image, err := f.srv.UploadProductImages(ctx, model.ProductImagesUploadReq{IsPrimary: "yes"}, product.Id, ".png", []byte("png"))
+	if err != nil {
+		t.Fatalf("UploadProductImages: %v", err)
+	}
+
+	stored, err := f.repo.FindProductImageById(ctx, image.Id)
+	if err != nil {
+		t.Fatalf("FindProductImageById: %v", err)
+	}
+	fileName := stored.ContentHash + ".png"
+	if !f.images.has(fileName) {
+		t.Fatalf("image file %s not saved", fileName)
+	}
+
+	_, err = f.srv.DeleteProduct(ctx, product.Id)
+	if err != nil {
+		t.Fatalf("DeleteProduct: %v", err)
+	}
+
+	purged, err := f.srv.PurgeTrash(ctx, time.Hour)
+	if err != nil || purged != 0 {
+		t.Fatalf("PurgeTrash within retention = %d, %v, want 0", purged, err)
+	}
+
+	purged, err = f.srv.PurgeTrash(ctx, -time.Second)
+	if err != nil || purged != 1 {
+		t.Fatalf("PurgeTrash past retention = %d, %v, want 1", purged, err)
+	}
+	if f.images.has(fileName) {
+		t.Errorf("image file %s kept after its product was purged", fileName)
+	}
+}
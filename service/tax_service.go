@@ -0,0 +1,74 @@
+package service
+
+import (
+	"fmt"
+	"learn/model"
+	"learn/repository"
+)
+
+type TaxService interface {
+	// ADMIN
+	AddTaxClass(req model.TaxClassReq) (model.TaxClassRes, error)
+	FindAllTaxClass() ([]model.TaxClassRes, error)
+	UpdateTaxClass(req model.TaxClassReq, taxClassId int) (model.TaxClassRes, error)
+}
+
+type taxService struct {
+	Repo repository.TaxRepository
+}
+
+func NewTaxService(repo repository.TaxRepository) TaxService {
+	return &taxService{
+		Repo: repo,
+	}
+}
+
+var (
+	emptyTaxClassRes   = model.TaxClassRes{}
+	emptyTaxClassesRes = []model.TaxClassRes{}
+)
+
+// AddTaxClass implements TaxService
+func (s *taxService) AddTaxClass(req model.TaxClassReq) (model.TaxClassRes, error) {
+	taxClass := model.TaxClass{
+		Code: req.Code,
+		Name: req.Name,
+		Rate: req.Rate,
+	}
+
+	taxClass, err := s.Repo.CreateTaxClass(taxClass)
+	if err != nil {
+		return emptyTaxClassRes, fmt.Errorf("CreateTaxClass call failed: %w", err)
+	}
+
+	return model.TaxClassFormatRes(taxClass), nil
+}
+
+// FindAllTaxClass implements TaxService
+func (s *taxService) FindAllTaxClass() ([]model.TaxClassRes, error) {
+	taxClasses, err := s.Repo.FindAllTaxClass()
+	if err != nil {
+		return emptyTaxClassesRes, fmt.Errorf("FindAllTaxClass call failed: %w", err)
+	}
+
+	return model.TaxClassesFormatRes(taxClasses), nil
+}
+
+// UpdateTaxClass implements TaxService
+func (s *taxService) UpdateTaxClass(req model.TaxClassReq, taxClassId int) (model.TaxClassRes, error) {
+	taxClass, err := s.Repo.FindTaxClassById(taxClassId)
+	if err != nil {
+		return emptyTaxClassRes, fmt.Errorf("FindTaxClassById call failed: %w", err)
+	}
+
+	taxClass.Code = req.Code
+	taxClass.Name = req.Name
+	taxClass.Rate = req.Rate
+
+	taxClass, err = s.Repo.UpdateTaxClass(taxClass)
+	if err != nil {
+		return emptyTaxClassRes, fmt.Errorf("UpdateTaxClass call failed: %w", err)
+	}
+
+	return model.TaxClassFormatRes(taxClass), nil
+}
@@ -1,38 +1,45 @@
 package service
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"learn/common"
 	"learn/config"
 	"learn/model"
 	"learn/repository"
+	"strconv"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserServive interface {
 	// PUBLIC
-	Register(req model.RegisterReq) (model.RegisterRes, error)
-	Login(req model.LoginReq) (model.LoginRes, error)
-	Profile(id int) (model.ProfileRes, error)
-	ChangePassword(id int, req model.ChangePassReq) (model.ChangePassRes, error)
+	Register(ctx context.Context, req model.RegisterReq) (model.RegisterRes, error)
+	Login(ctx context.Context, req model.LoginReq) (model.LoginRes, error)
+	Profile(ctx context.Context, id int) (model.ProfileRes, error)
+	ChangePassword(ctx context.Context, id int, req model.ChangePassReq) (model.ChangePassRes, error)
 	// ADMIN
-	RegisterAdmin(req model.RegisterAdminReq) (model.RegisterAdminRes, error)
+	RegisterAdmin(ctx context.Context, req model.RegisterAdminReq) (model.RegisterAdminRes, error)
 }
 
 type userService struct {
-	Repo repository.UserRepository
+	Repo   repository.UserRepository
+	Tokens *config.Tokens
+	// AdminCode is the code RegisterAdmin requires.
+	AdminCode string
 }
 
-func NewUserService(repo repository.UserRepository) UserServive {
+func NewUserService(repo repository.UserRepository, tokens *config.Tokens, adminCode string) UserServive {
 	return &userService{
-		Repo: repo,
+		Repo:      repo,
+		Tokens:    tokens,
+		AdminCode: adminCode,
 	}
 }
 
 var (
-	dbUser = model.User{}
-
 	// USER
 	emptyRegisRes      = model.RegisterRes{}
 	emptyLoginRes      = model.LoginRes{}
@@ -43,41 +50,32 @@ var (
 )
 
 // Register implements UserServive
-func (s *userService) Register(req model.RegisterReq) (model.RegisterRes, error) {
+func (s *userService) Register(ctx context.Context, req model.RegisterReq) (model.RegisterRes, error) {
 	passHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return emptyRegisRes, fmt.Errorf("GenerateFromPassword call failed: %w", err)
 	}
 
-	username, err := s.Repo.FindByUsername(req.Username)
-	if err != nil {
-		return emptyRegisRes, fmt.Errorf("FindByUsername call failed: %w", err)
-	}
-
-	if username.Id != 0 {
-		return emptyRegisRes, fmt.Errorf("user id %d : %w", username.Id, common.ErrNotFound)
-	}
-
-	userEmail, err := s.Repo.FindByEmail(req.Email)
+	err = s.checkAvailable(ctx, req.Username, req.Email)
 	if err != nil {
-		return emptyRegisRes, fmt.Errorf("FindByEmail call failed: %w", err)
+		return emptyRegisRes, err
 	}
 
-	if userEmail.Id != 0 {
-		return emptyRegisRes, fmt.Errorf("user email %d : %w", userEmail.Id, common.ErrNotFound)
+	user := model.User{
+		Username: req.Username,
+		Email:    req.Email,
+		Phone:    req.Phone,
+		Password: string(passHash),
+		Role:     "user",
 	}
 
-	dbUser.Username = req.Username
-	dbUser.Email = req.Email
-	dbUser.Password = string(passHash)
-	dbUser.Role = "user"
-
-	user, err := s.Repo.CreateUser(dbUser)
+	user, err = s.Repo.CreateUser(ctx, user)
 	if err != nil {
 		return emptyRegisRes, fmt.Errorf("CreateUser call failed: %w", err)
 	}
 
 	response := model.RegisterRes{
+		Id:       user.Id,
 		Username: user.Username,
 	}
 
@@ -85,23 +83,23 @@ func (s *userService) Register(req model.RegisterReq) (model.RegisterRes, error)
 }
 
 // Login implements UserServive
-func (s *userService) Login(req model.LoginReq) (model.LoginRes, error) {
-	user, err := s.Repo.FindByUsername(req.Username)
+func (s *userService) Login(ctx context.Context, req model.LoginReq) (model.LoginRes, error) {
+	user, err := s.Repo.FindByUsername(ctx, req.Username)
+	if errors.Is(err, common.ErrNotFound) {
+		return emptyLoginRes, fmt.Errorf("username %s : %w", req.Username, common.ErrInvalidCredentials)
+	}
 	if err != nil {
 		return emptyLoginRes, fmt.Errorf("FindByUsername call failed: %w", err)
 	}
-	if user.Id == 0 {
-		return emptyLoginRes, fmt.Errorf("user id %d : %w", user.Id, common.ErrNotFound)
-	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
 	if err != nil {
-		return emptyLoginRes, fmt.Errorf("CompareHashAndPassword call failed: %w", err)
+		return emptyLoginRes, fmt.Errorf("CompareHashAndPassword call failed: %v : %w", err, common.ErrInvalidCredentials)
 	}
 
-	token, err := config.CreateToken(user.Id, user.Role)
+	token, err := s.Tokens.Create(user.Id, user.Role)
 	if err != nil {
-		return emptyLoginRes, fmt.Errorf("CreateToken call failed: %w", err)
+		return emptyLoginRes, fmt.Errorf("Create token call failed: %w", err)
 	}
 
 	response := model.LoginRes{
@@ -112,19 +110,16 @@ func (s *userService) Login(req model.LoginReq) (model.LoginRes, error) {
 }
 
 // Profile implements UserServive
-func (s *userService) Profile(id int) (model.ProfileRes, error) {
-	user, err := s.Repo.FindByID(id)
+func (s *userService) Profile(ctx context.Context, id int) (model.ProfileRes, error) {
+	user, err := s.Repo.FindByID(ctx, id)
 	if err != nil {
 		return emptyProfileRes, fmt.Errorf("FindByID call failed: %w", err)
 	}
 
-	if user.Id == 0 {
-		return emptyProfileRes, fmt.Errorf("user id %d : %w", user.Id, common.ErrNotFound)
-	}
-
 	response := model.ProfileRes{
 		Username: user.Username,
 		Email:    user.Email,
+		Phone:    user.Phone,
 		Role:     user.Role,
 	}
 
@@ -132,23 +127,20 @@ func (s *userService) Profile(id int) (model.ProfileRes, error) {
 }
 
 // ChangePassword implements UserServive
-func (s *userService) ChangePassword(id int, req model.ChangePassReq) (model.ChangePassRes, error) {
+func (s *userService) ChangePassword(ctx context.Context, id int, req model.ChangePassReq) (model.ChangePassRes, error) {
 	newPass, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
 	if err != nil {
 		return emptyChangePassRes, fmt.Errorf("GenerateFromPassword call failed: %w", err)
 	}
 
-	user, err := s.Repo.FindByID(id)
+	user, err := s.Repo.FindByID(ctx, id)
 	if err != nil {
 		return emptyChangePassRes, fmt.Errorf("FindByID call failed: %w", err)
 	}
+
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
 	if err != nil {
-		return emptyChangePassRes, fmt.Errorf("CompareHashAndPassword call failed: %w", err)
-	}
-
-	if user.Id == 0 {
-		return emptyChangePassRes, fmt.Errorf("user id %d : %w", user.Id, common.ErrNotFound)
+		return emptyChangePassRes, fmt.Errorf("CompareHashAndPassword call failed: %v : %w", err, common.ErrInvalidCredentials)
 	}
 	if req.NewPassword != req.ConfirmPassword {
 		return emptyChangePassRes, fmt.Errorf("user passwored : %w", common.ErrNotMatch)
@@ -160,7 +152,7 @@ func (s *userService) ChangePassword(id int, req model.ChangePassReq) (model.Cha
 
 	user.Password = string(newPass)
 
-	_, err = s.Repo.SaveNewPassword(user)
+	_, err = s.Repo.SaveNewPassword(ctx, user)
 	if err != nil {
 		return emptyChangePassRes, fmt.Errorf("SaveNewPassword call failed: %w", err)
 	}
@@ -173,49 +165,59 @@ func (s *userService) ChangePassword(id int, req model.ChangePassReq) (model.Cha
 }
 
 // RegisterAdmin implements UserServive
-func (s *userService) RegisterAdmin(req model.RegisterAdminReq) (model.RegisterAdminRes, error) {
-	codeAdmin := 181910
-
+func (s *userService) RegisterAdmin(ctx context.Context, req model.RegisterAdminReq) (model.RegisterAdminRes, error) {
 	passHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return emptyRegisAdminRes, fmt.Errorf("GenerateFromPassword call failed: %w", err)
 	}
 
-	userUsername, err := s.Repo.FindByUsername(req.Username)
-	if err != nil {
-		return emptyRegisAdminRes, fmt.Errorf("FindByUsername call failed: %w", err)
+	code := strconv.Itoa(req.CodeAdmin)
+	if s.AdminCode == "" || subtle.ConstantTimeCompare([]byte(code), []byte(s.AdminCode)) != 1 {
+		return emptyRegisAdminRes, fmt.Errorf("admin code : %w", common.ErrForbidden)
 	}
 
-	if userUsername.Id != 0 {
-		return emptyRegisAdminRes, fmt.Errorf("admin email : %w", common.ErrExists)
+	err = s.checkAvailable(ctx, req.Username, req.Email)
+	if err != nil {
+		return emptyRegisAdminRes, err
 	}
 
-	if req.CodeAdmin != codeAdmin {
-		return emptyRegisAdminRes, fmt.Errorf("admin code : %w", common.ErrNotMatch)
+	newUser := model.User{
+		Username: req.Username,
+		Email:    req.Email,
+		Password: string(passHash),
+		Role:     "admin",
 	}
 
-	userEmail, err := s.Repo.FindByEmail(req.Email)
+	newUser, err = s.Repo.CreateUser(ctx, newUser)
 	if err != nil {
-		return emptyRegisAdminRes, fmt.Errorf("FindByEmail call failed: %w", err)
+		return emptyRegisAdminRes, fmt.Errorf("CreateUser call failed: %w", err)
 	}
 
-	if userEmail.Id != 0 {
-		return emptyRegisAdminRes, fmt.Errorf("admin email : %w", common.ErrExists)
+	response := model.RegisterAdminRes{
+		Id:       newUser.Id,
+		Username: newUser.Username,
 	}
 
-	dbUser.Username = req.Username
-	dbUser.Email = req.Email
-	dbUser.Password = string(passHash)
-	dbUser.Role = "admin"
+	return response, nil
+}
 
-	newUser, err := s.Repo.CreateUser(dbUser)
-	if err != nil {
-		return emptyRegisAdminRes, err
+// checkAvailable makes sure no user has the username or email yet.
+func (s *userService) checkAvailable(ctx context.Context, username string, email string) error {
+	_, err := s.Repo.FindByUsername(ctx, username)
+	if err == nil {
+		return fmt.Errorf("username %s : %w", username, common.ErrExists)
+	}
+	if !errors.Is(err, common.ErrNotFound) {
+		return fmt.Errorf("FindByUsername call failed: %w", err)
 	}
 
-	response := model.RegisterAdminRes{
-		Username: newUser.Username,
+	_, err = s.Repo.FindByEmail(ctx, email)
+	if err == nil {
+		return fmt.Errorf("user email %s : %w", email, common.ErrExists)
+	}
+	if !errors.Is(err, common.ErrNotFound) {
+		return fmt.Errorf("FindByEmail call failed: %w", err)
 	}
 
-	return response, nil
+	return nil
 }
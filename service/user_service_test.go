@@ -0,0 +1,143 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"learn/common"
+	"learn/config"
+	"learn/model"
+	"learn/repository/repotest"
+	"learn/service"
+	"testing"
+)
+
+var tokens = config.NewTokens("test-signing-key")
+
+const adminCode = "424242"
+
+func TestRegister(t *testing.T) {
+	ctx := context.Background()
+	existing := repotest.NewUser()
+	srv := service.NewUserService(repotest.NewUserRepository(existing), tokens, adminCode)
+
+	res, err := srv.Register(ctx, model.RegisterReq{Username: "budi", Email: "budi@example.com", Password: repotest.UserPassword})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if res.Id == 0 || res.Username != "budi" {
+		t.Errorf("Register = %+v, want id and username budi", res)
+	}
+
+	tests := []struct {
+		name string
+		req  model.RegisterReq
+	}{
+		{"taken username", model.RegisterReq{Username: existing.Username, Email: "other@example.com", Password: repotest.UserPassword}},
+		{"taken email", model.RegisterReq{Username: "other", Email: existing.Email, Password: repotest.UserPassword}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := srv.Register(ctx, tt.req)
+			if !errors.Is(err, common.ErrExists) {
+				t.Errorf("Register error = %v, want ErrExists", err)
+			}
+		})
+	}
+}
+
+func TestRegisterAdmin(t *testing.T) {
+	ctx := context.Background()
+	req := model.RegisterAdminReq{Username: "admin2", Email: "admin2@example.com", Password: repotest.UserPassword, CodeAdmin: 424242}
+
+	tests := []struct {
+		name      string
+		adminCode string
+		codeAdmin int
+	}{
+		{"wrong code", adminCode, 181910},
+		{"no code configured", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := service.NewUserService(repotest.NewUserRepository(), tokens, tt.adminCode)
+
+			req := req
+			req.CodeAdmin = tt.codeAdmin
+			_, err := srv.RegisterAdmin(ctx, req)
+			if !errors.Is(err, common.ErrForbidden) {
+				t.Errorf("RegisterAdmin error = %v, want ErrForbidden", err)
+			}
+		})
+	}
+
+	// A user registered just before must not lend the admin their phone.
+	srv := service.NewUserService(repotest.NewUserRepository(), tokens, adminCode)
+	_, err := srv.Register(ctx, model.RegisterReq{Username: "budi", Email: "budi@example.com", Phone: "08123456789", Password: repotest.UserPassword})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	res, err := srv.RegisterAdmin(ctx, req)
+	if err != nil {
+		t.Fatalf("RegisterAdmin: %v", err)
+	}
+
+	profile, err := srv.Profile(ctx, res.Id)
+	if err != nil || profile.Phone != "" || profile.Role != "admin" {
+		t.Errorf("admin profile = %+v, %v, want an admin without a phone", profile, err)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	ctx := context.Background()
+	user := repotest.NewUser(func(u *model.User) { u.Id = 7 })
+	srv := service.NewUserService(repotest.NewUserRepository(user), tokens, adminCode)
+
+	res, err := srv.Login(ctx, model.LoginReq{Username: user.Username, Password: repotest.UserPassword})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+
+	claims, err := tokens.Parse(res.Token)
+	if err != nil {
+		t.Fatalf("Parse token: %v", err)
+	}
+	if claims.UserId != user.Id || claims.Role != user.Role {
+		t.Errorf("token claims = %d %s, want %d %s", claims.UserId, claims.Role, user.Id, user.Role)
+	}
+
+	tests := []struct {
+		name string
+		req  model.LoginReq
+	}{
+		{"wrong password", model.LoginReq{Username: user.Username, Password: "Wrong#123"}},
+		{"unknown user", model.LoginReq{Username: "nobody", Password: repotest.UserPassword}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := srv.Login(ctx, tt.req)
+			if !errors.Is(err, common.ErrInvalidCredentials) {
+				t.Errorf("Login error = %v, want ErrInvalidCredentials", err)
+			}
+		})
+	}
+}
+
+func TestProfile(t *testing.T) {
+	ctx := context.Background()
+	user := repotest.NewUser(func(u *model.User) { u.Id = 3 })
+	srv := service.NewUserService(repotest.NewUserRepository(user), tokens, adminCode)
+
+	res, err := srv.Profile(ctx, user.Id)
+	if err != nil {
+		t.Fatalf("Profile: %v", err)
+	}
+	if res.Username != user.Username || res.Email != user.Email {
+		t.Errorf("Profile = %+v, want %s %s", res, user.Username, user.Email)
+	}
+
+	_, err = srv.Profile(ctx, 99)
+	if !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("Profile of missing user error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"learn/common"
+	"learn/model"
+	"learn/repository"
+)
+
+type WarehouseService interface {
+	// ADMIN
+	AddWarehouse(ctx context.Context, req model.WarehouseReq) (model.WarehouseRes, error)
+	FindAllWarehouse(ctx context.Context) ([]model.WarehouseRes, error)
+	UpdateWarehouse(ctx context.Context, req model.WarehouseReq, warehouseId int) (model.WarehouseRes, error)
+	FindStocksByWarehouseId(ctx context.Context, warehouseId int) ([]model.WarehouseStockRes, error)
+	TransferStock(ctx context.Context, req model.StockTransferReq, userId int) (model.StockTransferRes, error)
+	AllocateStock(ctx context.Context, req model.StockAllocationReq, userId int) (model.StockAllocationRes, error)
+}
+
+type warehouseService struct {
+	Repo        repository.WarehouseRepository
+	AddressRepo repository.AddressRepository
+}
+
+func NewWarehouseService(repo repository.WarehouseRepository, addressRepo repository.AddressRepository) WarehouseService {
+	return &warehouseService{
+		Repo:        repo,
+		AddressRepo: addressRepo,
+	}
+}
+
+var (
+	emptyWarehouseRes       = model.WarehouseRes{}
+	emptyWarehousesRes      = []model.WarehouseRes{}
+	emptyWarehouseStocksRes = []model.WarehouseStockRes{}
+	emptyStockTransferRes   = model.StockTransferRes{}
+	emptyStockAllocationRes = model.StockAllocationRes{}
+)
+
+// AddWarehouse implements WarehouseService
+func (s *warehouseService) AddWarehouse(ctx context.Context, req model.WarehouseReq) (model.WarehouseRes, error) {
+	warehouse := model.Warehouse{
+		Code:      req.Code,
+		Name:      req.Name,
+		Address:   req.Address,
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+	}
+
+	warehouse, err := s.Repo.CreateWarehouse(ctx, warehouse)
+	if err != nil {
+		return emptyWarehouseRes, fmt.Errorf("CreateWarehouse call failed: %w", err)
+	}
+
+	return model.WarehouseFormatRes(warehouse), nil
+}
+
+// FindAllWarehouse implements WarehouseService
+func (s *warehouseService) FindAllWarehouse(ctx context.Context) ([]model.WarehouseRes, error) {
+	warehouses, err := s.Repo.FindAllWarehouse(ctx)
+	if err != nil {
+		return emptyWarehousesRes, fmt.Errorf("FindAllWarehouse call failed: %w", err)
+	}
+
+	return model.WarehousesFormatRes(warehouses), nil
+}
+
+// UpdateWarehouse implements WarehouseService
+func (s *warehouseService) UpdateWarehouse(ctx context.Context, req model.WarehouseReq, warehouseId int) (model.WarehouseRes, error) {
+	warehouse, err := s.Repo.FindWarehouseById(ctx, warehouseId)
+	if err != nil {
+		return emptyWarehouseRes, fmt.Errorf("FindWarehouseById call failed: %w", err)
+	}
+
+	warehouse.Code = req.Code
+	warehouse.Name = req.Name
+	warehouse.Address = req.Address
+	warehouse.Latitude = req.Latitude
+	warehouse.Longitude = req.Longitude
+
+	warehouse, err = s.Repo.UpdateWarehouse(ctx, warehouse)
+	if err != nil {
+		return emptyWarehouseRes, fmt.Errorf("UpdateWarehouse call failed: %w", err)
+	}
+
+	return model.WarehouseFormatRes(warehouse), nil
+}
+
+// FindStocksByWarehouseId implements WarehouseService
+func (s *warehouseService) FindStocksByWarehouseId(ctx context.Context, warehouseId int) ([]model.WarehouseStockRes, error) {
+	stocks, err := s.Repo.FindStocksByWarehouseId(ctx, warehouseId)
+	if err != nil {
+		return emptyWarehouseStocksRes, fmt.Errorf("FindStocksByWarehouseId call failed: %w", err)
+	}
+
+	return model.WarehouseStocksFormatRes(stocks), nil
+}
+
+// TransferStock implements WarehouseService
+func (s *warehouseService) TransferStock(ctx context.Context, req model.StockTransferReq, userId int) (model.StockTransferRes, error) {
+	transfer := model.StockTransfer{
+		ProductId:       req.ProductId,
+		FromWarehouseId: req.FromWarehouseId,
+		ToWarehouseId:   req.ToWarehouseId,
+		Quantity:        req.Quantity,
+		UserId:          userId,
+	}
+
+	transfer, err := s.Repo.TransferStock(ctx, transfer)
+	if err != nil {
+		return emptyStockTransferRes, fmt.Errorf("TransferStock call failed: %w", err)
+	}
+
+	return model.StockTransferFormatRes(transfer), nil
+}
+
+// AllocateStock implements WarehouseService. Among the warehouses that can
+// fill the whole quantity it picks the one nearest to the address when both
+// have coordinates, and the one with the highest stock otherwise. The
+// quantity is then taken from that warehouse as a sale by userId; Available
+// is the warehouse's stock before the allocation.
+func (s *warehouseService) AllocateStock(ctx context.Context, req model.StockAllocationReq, userId int) (model.StockAllocationRes, error) {
+	address := model.Address{}
+
+	if req.AddressId != 0 {
+		var err error
+		address, err = s.AddressRepo.FindByAddressId(ctx, req.AddressId)
+		if err != nil {
+			return emptyStockAllocationRes, fmt.Errorf("FindByAddressId call failed: %w", err)
+		}
+	}
+
+	stocks, err := s.Repo.FindStocksByProductId(ctx, req.ProductId)
+	if err != nil {
+		return emptyStockAllocationRes, fmt.Errorf("FindStocksByProductId call failed: %w", err)
+	}
+
+	var nearest, highest *model.WarehouseStock
+	var nearestKm float64
+
+	for i := range stocks {
+		stock := &stocks[i]
+		if stock.Quantity < req.Quantity {
+			continue
+		}
+
+		if highest == nil || stock.Quantity > highest.Quantity {
+			highest = stock
+		}
+
+		warehouse := stock.Warehouse
+		if address.Latitude == nil || address.Longitude == nil || warehouse.Latitude == nil || warehouse.Longitude == nil {
+			continue
+		}
+
+		km := model.DistanceKm(*address.Latitude, *address.Longitude, *warehouse.Latitude, *warehouse.Longitude)
+		if nearest == nil || km < nearestKm {
+			nearest = stock
+			nearestKm = km
+		}
+	}
+
+	response := model.StockAllocationRes{}
+
+	switch {
+	case nearest != nil:
+		response.Warehouse = model.WarehouseFormatRes(nearest.Warehouse)
+		response.Available = nearest.Quantity
+		response.DistanceKm = &nearestKm
+	case highest != nil:
+		response.Warehouse = model.WarehouseFormatRes(highest.Warehouse)
+		response.Available = highest.Quantity
+	default:
+		return emptyStockAllocationRes, fmt.Errorf("product %d quantity %d : %w", req.ProductId, req.Quantity, common.ErrInsufficientStock)
+	}
+
+	movement := model.InventoryMovement{
+		ProductId:   req.ProductId,
+		WarehouseId: response.Warehouse.Id,
+		Type:        model.MovementSale,
+		Quantity:    -req.Quantity,
+		Reason:      "allocation",
+		UserId:      userId,
+	}
+
+	// The stocks were read without a lock; the repository checks the chosen
+	// warehouse again under one.
+	movement, err = s.Repo.AllocateStock(ctx, movement)
+	if err != nil {
+		return emptyStockAllocationRes, fmt.Errorf("AllocateStock call failed: %w", err)
+	}
+
+	response.Movement = model.InventoryMovementFormatRes(movement)
+
+	return response, nil
+}
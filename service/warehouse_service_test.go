@@ -0,0 +1,48 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"learn/common"
+	"learn/model"
+	"learn/service"
+	"testing"
+)
+
+func TestRestockIntoWarehouse(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeStockRepository(map[int]int{1: 10},
+		model.Warehouse{Id: 1, Code: "JKT", Name: "Jakarta"},
+		model.Warehouse{Id: 2, Code: "BDG", Name: "Bandung"},
+	)
+	inventory := service.NewInventoryService(repo, nil)
+	warehouses := service.NewWarehouseService(repo, nil)
+
+	movement, err := inventory.RecordMovement(model.InventoryMovementReq{Type: model.MovementRestock, Quantity: 5, WarehouseId: 1}, 1, 7)
+	if err != nil {
+		t.Fatalf("RecordMovement: %v", err)
+	}
+	if movement.WarehouseId != 1 || movement.BalanceAfter != 15 {
+		t.Errorf("movement = %+v, want warehouse 1 and balance 15", movement)
+	}
+
+	stocks, err := warehouses.FindStocksByWarehouseId(ctx, 1)
+	if err != nil || len(stocks) != 1 || stocks[0].Quantity != 5 {
+		t.Fatalf("warehouse 1 stocks = %+v, %v, want 5", stocks, err)
+	}
+
+	_, err = warehouses.TransferStock(ctx, model.StockTransferReq{ProductId: 1, FromWarehouseId: 1, ToWarehouseId: 2, Quantity: 3}, 7)
+	if err != nil {
+		t.Fatalf("TransferStock: %v", err)
+	}
+
+	stocks, err = warehouses.FindStocksByWarehouseId(ctx, 2)
+	if err != nil || len(stocks) != 1 || stocks[0].Quantity != 3 {
+		t.Errorf("warehouse 2 stocks = %+v, %v, want 3", stocks, err)
+	}
+
+	_, err = inventory.RecordMovement(model.InventoryMovementReq{Type: model.MovementRestock, Quantity: 5, WarehouseId: 9}, 1, 7)
+	if !errors.Is(err, common.ErrNotFound) {
+		t.Errorf("RecordMovement into a missing warehouse error = %v, want ErrNotFound", err)
+	}
+}